@@ -3,24 +3,21 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"flag"
+	"log/slog"
+	"os"
 	"time"
 
+	"github.com/gegxkss/wbL0/internal/config"
 	"github.com/google/uuid"
 	"github.com/segmentio/kafka-go"
 )
 
-const (
-	topic = "order"
-)
-
-var address = []string{"localhost:9092"}
-
 type Producer struct {
 	writer *kafka.Writer
 }
 
-func NewProducer(brokers []string) *Producer {
+func NewProducer(brokers []string, topic string) *Producer {
 	return &Producer{
 		writer: &kafka.Writer{
 			Addr:         kafka.TCP(brokers...),
@@ -49,7 +46,13 @@ func (p *Producer) Close() error {
 }
 
 func main() {
-	p := NewProducer(address)
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.Getenv)
+	if err != nil {
+		slog.Error("load config failed", "error", err)
+		os.Exit(1)
+	}
+
+	p := NewProducer(cfg.Kafka.Brokers, cfg.Kafka.Topic)
 	defer p.Close()
 
 	for i := 0; i < 3; i++ {
@@ -106,15 +109,15 @@ func main() {
 
 		jsonData, err := json.Marshal(order)
 		if err != nil {
-			log.Printf("Failed to marshal order to JSON: %v", err)
+			slog.Error("marshal order failed", "order_uid", orderUID, "error", err)
 			continue
 		}
 
 		key := uuid.New().String()
 		if err := p.Produce(string(jsonData), key); err != nil {
-			log.Printf("Failed to produce message: %v", err)
+			slog.Error("produce message failed", "order_uid", orderUID, "error", err)
 		} else {
-			log.Printf("Successfully produced message with key: %s, order_uid: %s", key, orderUID)
+			slog.Info("message produced", "key", key, "order_uid", orderUID)
 		}
 	}
 }
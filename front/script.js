@@ -1,4 +1,4 @@
-const API_BASE_URL = 'http://localhost:8081';
+const API_BASE_URL = 'http://localhost:8081/api/v1';
 
 function showLoading(show) {
     document.getElementById('loading').style.display = show ? 'block' : 'none';
@@ -33,13 +33,23 @@ function formatDate(dateString) {
     }
 }
 
-function formatCurrency(amount, currency) {
-    if (!amount) return 'Не указано';
-    const formatter = new Intl.NumberFormat('ru-RU', {
-        style: 'currency',
-        currency: currency || 'USD'
-    });
-    return formatter.format(amount);
+// money приходит из API как {amount, currency, decimal}. decimal уже содержит
+// число знаков валюты по таблице сервера: у Intl она может отличаться (IQD).
+function formatCurrency(money) {
+    if (!money || !money.currency) return 'Не указано';
+    const decimal = money.decimal ?? String(money.amount);
+    const digits = (decimal.split('.')[1] || '').length;
+    try {
+        return new Intl.NumberFormat('ru-RU', {
+            style: 'currency',
+            currency: money.currency,
+            minimumFractionDigits: digits,
+            maximumFractionDigits: digits
+        }).format(decimal);
+    } catch {
+        // Некорректный код валюты не должен ломать отображение заказа
+        return `${decimal} ${money.currency}`;
+    }
 }
 
 function searchOrder() {
@@ -54,7 +64,7 @@ function searchOrder() {
     showLoading(true);
     document.getElementById('resultSection').style.display = 'none';
 
-    fetch(`${API_BASE_URL}/order/${orderId}`)
+    fetch(`${API_BASE_URL}/orders/${encodeURIComponent(orderId)}`)
         .then(response => {
             if (!response.ok) {
                 if (response.status === 404) {
@@ -77,6 +87,80 @@ function searchOrder() {
         });
 }
 
+const SEARCH_FIELDS = {
+    'items.name': 'Товар',
+    'items.brand': 'Бренд',
+    'delivery.name': 'Получатель',
+    'delivery.city': 'Город',
+    'delivery.phone': 'Телефон'
+};
+
+function escapeHtml(value) {
+    const div = document.createElement('div');
+    div.textContent = value ?? '';
+    return div.innerHTML;
+}
+
+function searchOrders() {
+    const query = document.getElementById('searchQueryInput').value.trim();
+    const resultsDiv = document.getElementById('searchResults');
+
+    if (query.length < 2) {
+        showError('Введите хотя бы 2 символа для поиска');
+        return;
+    }
+
+    hideMessages();
+    showLoading(true);
+    resultsDiv.innerHTML = '';
+
+    fetch(`${API_BASE_URL}/search?q=${encodeURIComponent(query)}`)
+        .then(response => {
+            if (!response.ok) {
+                throw new Error('Ошибка сервера: ' + response.status);
+            }
+            return response.json();
+        })
+        .then(data => {
+            displaySearchResults(data.results);
+        })
+        .catch(error => {
+            showError(error.message);
+        })
+        .finally(() => {
+            showLoading(false);
+        });
+}
+
+// highlight приходит с сервера уже экранированным, совпадения в <mark>.
+function displaySearchResults(results) {
+    const resultsDiv = document.getElementById('searchResults');
+    if (!results || results.length === 0) {
+        resultsDiv.innerHTML = '<div class="search-result">Ничего не найдено</div>';
+        return;
+    }
+
+    resultsDiv.innerHTML = results.map((result, index) => `
+        <div class="search-result" data-index="${index}">
+            <div class="search-result-title">${escapeHtml(result.order.order_uid)}
+                <span class="search-result-date">${formatDate(result.order.date_created)}</span>
+            </div>
+            ${result.matches.map(match => `
+                <div class="search-result-match">
+                    <span class="info-label">${SEARCH_FIELDS[match.field] || escapeHtml(match.field)}</span>
+                    ${match.highlight}
+                </div>
+            `).join('')}
+        </div>
+    `).join('');
+
+    resultsDiv.querySelectorAll('.search-result[data-index]').forEach(element => {
+        element.addEventListener('click', () => {
+            displayOrderData(results[element.dataset.index].order);
+        });
+    });
+}
+
 function displayOrderData(order) {
     const orderDetails = document.getElementById('orderDetails');
     orderDetails.innerHTML = `
@@ -153,7 +237,7 @@ function displayOrderData(order) {
             </div>
             <div class="info-item">
                 <span class="info-label">Сумма</span>
-                <span class="info-value">${formatCurrency(order.payment.amount, order.payment.currency)}</span>
+                <span class="info-value">${formatCurrency(order.payment.amount)}</span>
             </div>
             <div class="info-item">
                 <span class="info-label">Валюта</span>
@@ -169,15 +253,15 @@ function displayOrderData(order) {
             </div>
             <div class="info-item">
                 <span class="info-label">Стоимость доставки</span>
-                <span class="info-value">${formatCurrency(order.payment.delivery_cost, order.payment.currency)}</span>
+                <span class="info-value">${formatCurrency(order.payment.delivery_cost)}</span>
             </div>
             <div class="info-item">
                 <span class="info-label">Стоимость товаров</span>
-                <span class="info-value">${formatCurrency(order.payment.goods_total, order.payment.currency)}</span>
+                <span class="info-value">${formatCurrency(order.payment.goods_total)}</span>
             </div>
             <div class="info-item">
                 <span class="info-label">Дата оплаты</span>
-                <span class="info-value">${formatDate(new Date(order.payment.payment_dt * 1000))}</span>
+                <span class="info-value">${formatDate(order.payment.payment_dt)}</span>
             </div>
         `;
     } else {
@@ -189,9 +273,9 @@ function displayOrderData(order) {
             <tr>
                 <td>${item.name || 'Не указано'}</td>
                 <td>${item.brand || 'Не указано'}</td>
-                <td>${formatCurrency(item.price, order.payment?.currency)}</td>
-                <td>${item.quantity || Math.round(item.total_price / item.price) || 1}</td>
-                <td>${formatCurrency(item.total_price, order.payment?.currency)}</td>
+                <td>${formatCurrency(item.price)}</td>
+                <td>${item.quantity || Math.round(item.total_price?.amount / item.price?.amount) || 1}</td>
+                <td>${formatCurrency(item.total_price)}</td>
                 <td>${item.status || 'Не указано'}</td>
             </tr>
         `).join('');
@@ -201,10 +285,71 @@ function displayOrderData(order) {
     document.getElementById('resultSection').style.display = 'block';
 }
 
+const LIVE_ORDERS_LIMIT = 10;
+const liveOrders = [];
+
+// Лента новых заказов через Server-Sent Events. EventSource сам
+// переподключается и передает Last-Event-ID, поэтому пропущенные
+// за время обрыва заказы досылаются сервером.
+function watchOrders() {
+    const status = document.getElementById('liveStatus');
+    const source = new EventSource(`${API_BASE_URL}/orders/stream`);
+
+    source.onopen = () => {
+        status.textContent = '● онлайн';
+    };
+    source.onerror = () => {
+        status.textContent = '○ переподключение...';
+    };
+    source.addEventListener('order', event => {
+        liveOrders.unshift(JSON.parse(event.data));
+        liveOrders.splice(LIVE_ORDERS_LIMIT);
+        displayLiveOrders();
+    });
+    // Часть заказов восстановить не удалось: лента начинается заново
+    source.addEventListener('reset', () => {
+        liveOrders.length = 0;
+        displayLiveOrders();
+    });
+}
+
+function displayLiveOrders() {
+    const liveDiv = document.getElementById('liveOrders');
+    if (liveOrders.length === 0) {
+        liveDiv.innerHTML = '<div class="search-result">Новых заказов пока нет</div>';
+        return;
+    }
+
+    liveDiv.innerHTML = liveOrders.map((order, index) => `
+        <div class="search-result" data-index="${index}">
+            <div class="search-result-title">${escapeHtml(order.order_uid)}
+                <span class="search-result-date">${formatDate(order.date_created)}</span>
+            </div>
+            <div class="search-result-match">
+                ${escapeHtml(order.delivery.name)}, ${escapeHtml(order.delivery_service)} — ${formatCurrency(order.payment.amount)}
+            </div>
+        </div>
+    `).join('');
+
+    liveDiv.querySelectorAll('.search-result[data-index]').forEach(element => {
+        element.addEventListener('click', () => {
+            displayOrderData(liveOrders[element.dataset.index]);
+        });
+    });
+}
+
 document.getElementById('orderIdInput').addEventListener('keypress', function(e) {
     if (e.key === 'Enter') {
         searchOrder();
     }
 });
 
-document.getElementById('orderIdInput').focus();
\ No newline at end of file
+document.getElementById('searchQueryInput').addEventListener('keypress', function(e) {
+    if (e.key === 'Enter') {
+        searchOrders();
+    }
+});
+
+document.getElementById('orderIdInput').focus();
+displayLiveOrders();
+watchOrders();
@@ -0,0 +1,182 @@
+package broadcast
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/models"
+)
+
+// DefaultBuffer — число событий, которое подписчик может не прочитать,
+// прежде чем будет отключен.
+const DefaultBuffer = 64
+
+// DefaultHistory — сколько последних событий хранится для переподключения
+// с Last-Event-ID.
+const DefaultHistory = 1000
+
+// Event — сохраненный заказ с порядковым номером публикации. Номера идут
+// подряд и начинаются с времени запуска в микросекундах, поэтому не
+// повторяются после перезапуска сервиса.
+type Event struct {
+	ID    uint64
+	Order *models.Order
+}
+
+// Filter отбирает заказы для подписчика; пустые поля не фильтруют.
+type Filter struct {
+	CustomerID      string
+	DeliveryService string
+}
+
+func (f Filter) Match(order *models.Order) bool {
+	return (f.CustomerID == "" || order.CustomerId == f.CustomerID) &&
+		(f.DeliveryService == "" || order.DeliveryService == f.DeliveryService)
+}
+
+// Broadcaster рассылает сохраненные заказы подписчикам (gRPC WatchOrders,
+// SSE GET /api/v1/orders/stream). Publish никогда не блокируется: подписчик,
+// не успевающий читать события, отключается, его канал закрывается,
+// а Dropped возвращает true. Последние события хранятся, чтобы
+// переподключившийся подписчик получил пропущенное.
+type Broadcaster struct {
+	mu      sync.Mutex
+	lastID  uint64
+	subs    map[*Subscription]struct{}
+	buffer  int
+	history []Event
+	// historySize — емкость history, в тестах уменьшается.
+	historySize int
+	closed      bool
+	log         *slog.Logger
+}
+
+func New(buffer int, log *slog.Logger) *Broadcaster {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Broadcaster{
+		lastID:      uint64(time.Now().UnixMicro()),
+		subs:        make(map[*Subscription]struct{}),
+		buffer:      buffer,
+		historySize: DefaultHistory,
+		log:         log.With("component", "broadcast"),
+	}
+}
+
+type Subscription struct {
+	b       *Broadcaster
+	filter  Filter
+	events  chan Event
+	dropped bool
+}
+
+// Subscribe подписывает на заказы, подходящие под фильтр. Подписку нужно
+// закрыть вызовом Close.
+func (b *Broadcaster) Subscribe(filter Filter) *Subscription {
+	s, _, _ := b.SubscribeFrom(filter, 0)
+	return s
+}
+
+// SubscribeFrom подписывает на заказы и возвращает подходящие под фильтр
+// события после lastID из истории. Подписка оформляется под той же
+// блокировкой, поэтому между историей и новыми событиями нет пропусков
+// и повторов. complete равен false, если часть событий после lastID уже
+// вытеснена из истории или lastID выдан до перезапуска: подписчику нужно
+// перечитать данные целиком. Нулевой lastID означает подписку без истории.
+func (b *Broadcaster) SubscribeFrom(filter Filter, lastID uint64) (s *Subscription, replay []Event, complete bool) {
+	s = &Subscription{b: b, filter: filter, events: make(chan Event, b.buffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(s.events)
+		return s, nil, true
+	}
+	b.subs[s] = struct{}{}
+	subscribersGauge.Inc()
+
+	if lastID == 0 {
+		return s, nil, true
+	}
+	oldest := b.lastID + 1 - uint64(len(b.history))
+	if lastID+1 < oldest || lastID > b.lastID {
+		return s, nil, false
+	}
+	for _, e := range b.history[lastID+1-oldest:] {
+		if filter.Match(e.Order) {
+			replay = append(replay, e)
+		}
+	}
+	return s, replay, true
+}
+
+// Publish отправляет заказ всем подходящим подписчикам.
+func (b *Broadcaster) Publish(order *models.Order) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{ID: b.lastID, Order: order}
+	publishedOrders.Inc()
+	if len(b.history) > 0 && len(b.history) >= b.historySize {
+		copy(b.history, b.history[1:])
+		b.history = b.history[:len(b.history)-1]
+	}
+	b.history = append(b.history, event)
+	for s := range b.subs {
+		if !s.filter.Match(order) {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			s.dropped = true
+			b.remove(s)
+			droppedSubscribers.Inc()
+			b.log.Warn("slow subscriber dropped", "buffer", b.buffer)
+		}
+	}
+}
+
+// Close отключает всех подписчиков, например при остановке сервиса.
+// Новые подписки после Close сразу закрыты.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subs {
+		b.remove(s)
+	}
+}
+
+// remove вызывается под b.mu.
+func (b *Broadcaster) remove(s *Subscription) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	close(s.events)
+	subscribersGauge.Dec()
+}
+
+// Events возвращает канал событий; он закрывается после Close или отключения.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped сообщает, что подписка отключена из-за переполнения буфера.
+func (s *Subscription) Dropped() bool {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	return s.dropped
+}
+
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	s.b.remove(s)
+}
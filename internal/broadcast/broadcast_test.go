@@ -0,0 +1,120 @@
+package broadcast
+
+import (
+	"testing"
+
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+)
+
+func TestBroadcaster_Filter(t *testing.T) {
+	b := New(4, logger.Nop())
+	all := b.Subscribe(Filter{})
+	defer all.Close()
+	dhl := b.Subscribe(Filter{DeliveryService: "dhl"})
+	defer dhl.Close()
+
+	b.Publish(&models.Order{OrderUID: "a", DeliveryService: "meest"})
+	b.Publish(&models.Order{OrderUID: "b", DeliveryService: "dhl"})
+
+	first := <-all.Events()
+	if first.Order.OrderUID != "a" {
+		t.Errorf("Expected order a, got %+v", first)
+	}
+	if e := <-all.Events(); e.ID != first.ID+1 || e.Order.OrderUID != "b" {
+		t.Errorf("Expected next event for order b, got %+v", e)
+	}
+	if e := <-dhl.Events(); e.Order.OrderUID != "b" {
+		t.Errorf("Expected only dhl order, got %+v", e)
+	}
+	if len(dhl.Events()) != 0 {
+		t.Error("Filtered subscriber received extra events")
+	}
+}
+
+func TestBroadcaster_DropsSlowSubscriber(t *testing.T) {
+	b := New(2, logger.Nop())
+	slow := b.Subscribe(Filter{})
+	fast := b.Subscribe(Filter{})
+	defer fast.Close()
+
+	for _, uid := range []string{"a", "b", "c"} {
+		b.Publish(&models.Order{OrderUID: uid})
+		<-fast.Events()
+	}
+
+	if !slow.Dropped() {
+		t.Fatal("Expected slow subscriber to be dropped")
+	}
+	var got int
+	for range slow.Events() {
+		got++
+	}
+	if got != 2 {
+		t.Errorf("Expected 2 buffered events before drop, got %d", got)
+	}
+	if fast.Dropped() {
+		t.Error("Fast subscriber must not be dropped")
+	}
+	slow.Close() // повторное закрытие безопасно
+}
+
+func TestBroadcaster_SubscribeFrom(t *testing.T) {
+	b := New(4, logger.Nop())
+	b.historySize = 3
+	sub := b.Subscribe(Filter{})
+	defer sub.Close()
+
+	var ids []uint64
+	for _, uid := range []string{"a", "b", "c", "d"} {
+		b.Publish(&models.Order{OrderUID: uid, CustomerId: "c-" + uid})
+		ids = append(ids, (<-sub.Events()).ID)
+	}
+
+	// После b в истории остались c и d, фильтр оставляет только d
+	resumed, replay, complete := b.SubscribeFrom(Filter{CustomerID: "c-d"}, ids[1])
+	defer resumed.Close()
+	if !complete || len(replay) != 1 || replay[0].ID != ids[3] {
+		t.Errorf("Expected replay of d, got %+v (complete %v)", replay, complete)
+	}
+	b.Publish(&models.Order{OrderUID: "e", CustomerId: "c-d"})
+	if e := <-resumed.Events(); e.Order.OrderUID != "e" {
+		t.Errorf("Expected live event after replay, got %+v", e)
+	}
+
+	cases := map[string]uint64{
+		"evicted":     ids[0],
+		"future":      ids[3] + 100,
+		"old process": 42,
+	}
+	for name, lastID := range cases {
+		s, replay, complete := b.SubscribeFrom(Filter{}, lastID)
+		s.Close()
+		if complete || replay != nil {
+			t.Errorf("%s: expected incomplete history, got %+v", name, replay)
+		}
+	}
+
+	latest, replay, complete := b.SubscribeFrom(Filter{}, b.lastID)
+	latest.Close()
+	if !complete || len(replay) != 0 {
+		t.Errorf("Expected empty complete replay for latest ID, got %+v (complete %v)", replay, complete)
+	}
+}
+
+func TestBroadcaster_Close(t *testing.T) {
+	b := New(4, logger.Nop())
+	sub := b.Subscribe(Filter{})
+
+	b.Close()
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Expected subscription to be closed")
+	}
+	if sub.Dropped() {
+		t.Error("Closed subscription must not be reported as dropped")
+	}
+	if _, ok := <-b.Subscribe(Filter{}).Events(); ok {
+		t.Error("Expected subscription after Close to be closed")
+	}
+	b.Publish(&models.Order{OrderUID: "a"})
+}
@@ -0,0 +1,23 @@
+package broadcast
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	subscribersGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "broadcast_subscribers",
+		Help: "Number of active subscribers to stored orders.",
+	})
+
+	publishedOrders = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "broadcast_published_total",
+		Help: "Number of stored orders published to subscribers.",
+	})
+
+	droppedSubscribers = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "broadcast_dropped_subscribers_total",
+		Help: "Number of subscribers disconnected because their buffer was full.",
+	})
+)
@@ -2,27 +2,40 @@ package cache
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 )
 
 type Cache struct {
 	items   map[string]interface{}
+	indexes map[string]*index
 	size    int
 	maxSize int
 	mu      sync.RWMutex
+	log     *slog.Logger
+	warm    atomic.Bool
 }
 
 const (
 	cacheMaxSize = 1000
 )
 
-func NewCache() *Cache {
-	return &Cache{
+func NewCache(log *slog.Logger) *Cache {
+	return NewCacheWithSize(cacheMaxSize, log)
+}
+
+func NewCacheWithSize(maxSize int, log *slog.Logger) *Cache {
+	c := &Cache{
 		items:   make(map[string]interface{}),
+		indexes: make(map[string]*index),
 		size:    0,
-		maxSize: cacheMaxSize,
+		maxSize: maxSize,
+		log:     log,
 	}
+	c.AddIndex(IndexTrackNumber, trackNumberKeys)
+	c.AddIndex(IndexTransaction, transactionKeys)
+	return c
 }
 
 func (c *Cache) Set(orderUID string, value interface{}) error {
@@ -33,12 +46,19 @@ func (c *Cache) Set(orderUID string, value interface{}) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.size >= c.maxSize {
+	old, exists := c.items[orderUID]
+	if !exists && c.size >= c.maxSize {
 		c.deleteLast()
 	}
 
+	if exists {
+		c.unindex(orderUID, old)
+	} else {
+		c.size++
+	}
 	c.items[orderUID] = value
-	c.size++
+	c.reindex(orderUID, value)
+	cacheSize.Set(float64(c.size))
 
 	return nil
 }
@@ -48,14 +68,57 @@ func (c *Cache) Get(orderUID string) (interface{}, bool) {
 	defer c.mu.RUnlock()
 
 	item, exists := c.items[orderUID]
+	if exists {
+		cacheHits.Inc()
+		c.log.Debug("cache hit", "order_uid", orderUID)
+	} else {
+		cacheMisses.Inc()
+		c.log.Debug("cache miss", "order_uid", orderUID)
+	}
 	return item, exists
 }
 
 func (c *Cache) deleteLast() {
 	for orderUID := range c.items {
-		delete(c.items, orderUID)
-		c.size--
-		log.Printf("Removed order from cache: %s", orderUID)
+		c.evict(orderUID)
 		break
 	}
 }
+
+// evict удаляет заказ вместе со всеми заказами, у которых есть общий с ним
+// ключ индекса. Иначе после вытеснения более нового заказа поиск по ключу
+// вернул бы из кэша более старый, а не тот, что вернет репозиторий.
+func (c *Cache) evict(orderUID string) {
+	queue := []string{orderUID}
+	for len(queue) > 0 {
+		uid := queue[0]
+		queue = queue[1:]
+		value, ok := c.items[uid]
+		if !ok {
+			continue
+		}
+		for _, idx := range c.indexes {
+			for _, key := range idx.keys(value) {
+				for other := range idx.entries[key] {
+					if other != uid {
+						queue = append(queue, other)
+					}
+				}
+			}
+		}
+		c.unindex(uid, value)
+		delete(c.items, uid)
+		c.size--
+		cacheEvictions.Inc()
+		c.log.Debug("order evicted from cache", "order_uid", uid)
+	}
+}
+
+// MarkWarm отмечает, что начальное заполнение кэша из базы завершено.
+func (c *Cache) MarkWarm() {
+	c.warm.Store(true)
+}
+
+func (c *Cache) Warm() bool {
+	return c.warm.Load()
+}
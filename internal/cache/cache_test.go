@@ -2,10 +2,13 @@ package cache
 
 import (
 	"testing"
+
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestNewCache(t *testing.T) {
-	c := NewCache()
+	c := NewCache(logger.Nop())
 	if c == nil {
 		t.Fatal("Cache is nil")
 	}
@@ -15,7 +18,7 @@ func TestNewCache(t *testing.T) {
 }
 
 func TestSetAndGet(t *testing.T) {
-	c := NewCache()
+	c := NewCache(logger.Nop())
 	orderUID := "test-uid"
 	value := "test-value"
 	if err := c.Set(orderUID, value); err != nil {
@@ -31,8 +34,42 @@ func TestSetAndGet(t *testing.T) {
 }
 
 func TestSetNilValue(t *testing.T) {
-	c := NewCache()
+	c := NewCache(logger.Nop())
 	if err := c.Set("uid", nil); err == nil {
 		t.Error("Expected error for nil value")
 	}
 }
+
+func TestCacheMetrics(t *testing.T) {
+	c := NewCache(logger.Nop())
+	c.maxSize = 1
+	hits := testutil.ToFloat64(cacheHits)
+	misses := testutil.ToFloat64(cacheMisses)
+	evictions := testutil.ToFloat64(cacheEvictions)
+
+	c.Set("a", "value")
+	c.Get("a")
+	c.Get("missing")
+	c.Set("b", "value")
+
+	if got := testutil.ToFloat64(cacheHits); got != hits+1 {
+		t.Errorf("Expected %v hits, got %v", hits+1, got)
+	}
+	if got := testutil.ToFloat64(cacheMisses); got != misses+1 {
+		t.Errorf("Expected %v misses, got %v", misses+1, got)
+	}
+	if got := testutil.ToFloat64(cacheEvictions); got != evictions+1 {
+		t.Errorf("Expected %v evictions, got %v", evictions+1, got)
+	}
+}
+
+func TestMarkWarm(t *testing.T) {
+	c := NewCache(logger.Nop())
+	if c.Warm() {
+		t.Error("New cache should not be warm")
+	}
+	c.MarkWarm()
+	if !c.Warm() {
+		t.Error("Cache should be warm after MarkWarm")
+	}
+}
@@ -0,0 +1,129 @@
+package cache
+
+import "github.com/gegxkss/wbL0/internal/models"
+
+const (
+	IndexTrackNumber = "track_number"
+	IndexTransaction = "transaction"
+)
+
+// KeyFunc возвращает ключи вторичного индекса для значения из кэша.
+// Для значений, которые не нужно индексировать, возвращает nil.
+type KeyFunc func(value interface{}) []string
+
+// index сопоставляет ключ (например, трек-номер) с order_uid всех
+// закэшированных заказов, у которых он есть. Поиск по ключу возвращает самый
+// новый по date_created заказ, как и репозиторий. Заказы с общим ключом
+// вытесняются из кэша вместе (см. evict).
+type index struct {
+	keys    KeyFunc
+	entries map[string]map[string]struct{}
+}
+
+func (idx *index) add(key, orderUID string) {
+	uids, ok := idx.entries[key]
+	if !ok {
+		uids = make(map[string]struct{}, 1)
+		idx.entries[key] = uids
+	}
+	uids[orderUID] = struct{}{}
+}
+
+func (idx *index) remove(key, orderUID string) {
+	if uids, ok := idx.entries[key]; ok {
+		delete(uids, orderUID)
+		if len(uids) == 0 {
+			delete(idx.entries, key)
+		}
+	}
+}
+
+// AddIndex добавляет вторичный индекс и строит его по уже сохраненным значениям.
+func (c *Cache) AddIndex(name string, keys KeyFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx := &index{keys: keys, entries: make(map[string]map[string]struct{})}
+	c.indexes[name] = idx
+	for orderUID, value := range c.items {
+		for _, key := range keys(value) {
+			idx.add(key, orderUID)
+		}
+	}
+}
+
+// GetBy ищет значение по ключу вторичного индекса.
+func (c *Cache) GetBy(indexName, key string) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var item interface{}
+	exists := false
+	if idx, ok := c.indexes[indexName]; ok {
+		var newestUID string
+		for orderUID := range idx.entries[key] {
+			value := c.items[orderUID]
+			if !exists || newer(value, orderUID, item, newestUID) {
+				item, newestUID, exists = value, orderUID, true
+			}
+		}
+	}
+	if exists {
+		cacheHits.Inc()
+		c.log.Debug("cache hit", "index", indexName, "key", key)
+	} else {
+		cacheMisses.Inc()
+		c.log.Debug("cache miss", "index", indexName, "key", key)
+	}
+	return item, exists
+}
+
+func (c *Cache) GetByTrackNumber(trackNumber string) (interface{}, bool) {
+	return c.GetBy(IndexTrackNumber, trackNumber)
+}
+
+func (c *Cache) GetByTransaction(transaction string) (interface{}, bool) {
+	return c.GetBy(IndexTransaction, transaction)
+}
+
+// reindex и unindex вызываются под c.mu.
+func (c *Cache) reindex(orderUID string, value interface{}) {
+	for _, idx := range c.indexes {
+		for _, key := range idx.keys(value) {
+			idx.add(key, orderUID)
+		}
+	}
+}
+
+func (c *Cache) unindex(orderUID string, value interface{}) {
+	for _, idx := range c.indexes {
+		for _, key := range idx.keys(value) {
+			idx.remove(key, orderUID)
+		}
+	}
+}
+
+// newer сравнивает заказы так же, как репозиторий: по date_created,
+// при равенстве — по order_uid. Для прочих значений учитывается только ключ.
+func newer(a interface{}, aUID string, b interface{}, bUID string) bool {
+	orderA, okA := a.(*models.Order)
+	orderB, okB := b.(*models.Order)
+	if okA && okB && !orderA.DateCreated.Equal(orderB.DateCreated) {
+		return orderA.DateCreated.After(orderB.DateCreated)
+	}
+	return aUID > bUID
+}
+
+func trackNumberKeys(value interface{}) []string {
+	if order, ok := value.(*models.Order); ok && order.TrackNumber != "" {
+		return []string{order.TrackNumber}
+	}
+	return nil
+}
+
+func transactionKeys(value interface{}) []string {
+	if order, ok := value.(*models.Order); ok && order.Payment.Transaction != "" {
+		return []string{order.Payment.Transaction}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+)
+
+func TestGetByIndexes(t *testing.T) {
+	c := NewCache(logger.Nop())
+	order := &models.Order{OrderUID: "uid-1", TrackNumber: "TRACK-1", Payment: models.Payment{Transaction: "tx-1"}}
+	c.Set(order.OrderUID, order)
+
+	if got, ok := c.GetByTrackNumber("TRACK-1"); !ok || got != order {
+		t.Errorf("Expected order by track number, got %v, %v", got, ok)
+	}
+	if got, ok := c.GetByTransaction("tx-1"); !ok || got != order {
+		t.Errorf("Expected order by transaction, got %v, %v", got, ok)
+	}
+	if _, ok := c.GetByTrackNumber("missing"); ok {
+		t.Error("Expected miss for unknown track number")
+	}
+
+	// Обновление заказа переносит ключи индекса
+	updated := &models.Order{OrderUID: "uid-1", TrackNumber: "TRACK-2"}
+	c.Set(updated.OrderUID, updated)
+	if _, ok := c.GetByTrackNumber("TRACK-1"); ok {
+		t.Error("Old track number still indexed")
+	}
+	if _, ok := c.GetByTransaction("tx-1"); ok {
+		t.Error("Old transaction still indexed")
+	}
+	if got, ok := c.GetByTrackNumber("TRACK-2"); !ok || got != updated {
+		t.Errorf("Expected updated order, got %v, %v", got, ok)
+	}
+}
+
+func TestIndexEviction(t *testing.T) {
+	c := NewCacheWithSize(1, logger.Nop())
+	c.Set("uid-1", &models.Order{OrderUID: "uid-1", TrackNumber: "TRACK-1"})
+	c.Set("uid-2", &models.Order{OrderUID: "uid-2", TrackNumber: "TRACK-2"})
+
+	if _, ok := c.GetByTrackNumber("TRACK-1"); ok {
+		t.Error("Evicted order still reachable by track number")
+	}
+	if _, ok := c.GetByTrackNumber("TRACK-2"); !ok {
+		t.Error("Expected order by track number after eviction of another")
+	}
+}
+
+func TestAddIndexBuildsFromExistingItems(t *testing.T) {
+	c := NewCache(logger.Nop())
+	c.Set("a", "value-a")
+	c.AddIndex("upper", func(v interface{}) []string {
+		if s, ok := v.(string); ok {
+			return []string{s + "-key"}
+		}
+		return nil
+	})
+
+	if got, ok := c.GetBy("upper", "value-a-key"); !ok || got != "value-a" {
+		t.Errorf("Expected value-a, got %v, %v", got, ok)
+	}
+	if _, ok := c.GetBy("unknown", "value-a-key"); ok {
+		t.Error("Expected miss for unknown index")
+	}
+}
+
+func TestIndexPicksNewestOrder(t *testing.T) {
+	c := NewCache(logger.Nop())
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newest := &models.Order{OrderUID: "uid-a", TrackNumber: "TRACK", DateCreated: base.Add(time.Hour)}
+	older := &models.Order{OrderUID: "uid-b", TrackNumber: "TRACK", DateCreated: base}
+
+	// Порядок добавления не важен: возвращается самый новый заказ
+	c.Set(newest.OrderUID, newest)
+	c.Set(older.OrderUID, older)
+	if got, ok := c.GetByTrackNumber("TRACK"); !ok || got != newest {
+		t.Errorf("Expected newest order, got %v, %v", got, ok)
+	}
+
+	// При одинаковой дате побеждает больший order_uid, как в репозитории
+	same := &models.Order{OrderUID: "uid-c", TrackNumber: "TRACK", DateCreated: base}
+	c.Set(same.OrderUID, same)
+	if got, ok := c.GetByTrackNumber("TRACK"); !ok || got != newest {
+		t.Errorf("Expected newest order, got %v, %v", got, ok)
+	}
+	c.Set("uid-d", &models.Order{OrderUID: "uid-d", TrackNumber: "OTHER", DateCreated: base})
+	c.Set("uid-e", &models.Order{OrderUID: "uid-e", TrackNumber: "OTHER", DateCreated: base})
+	if got, ok := c.GetByTrackNumber("OTHER"); !ok || got.(*models.Order).OrderUID != "uid-e" {
+		t.Errorf("Expected uid-e on equal dates, got %v, %v", got, ok)
+	}
+}
+
+func TestIndexEvictsWholeGroup(t *testing.T) {
+	c := NewCacheWithSize(3, logger.Nop())
+	c.Set("uid-1", &models.Order{OrderUID: "uid-1", TrackNumber: "TRACK", Payment: models.Payment{Transaction: "tx-1"}})
+	c.Set("uid-2", &models.Order{OrderUID: "uid-2", TrackNumber: "TRACK"})
+	c.Set("uid-3", &models.Order{OrderUID: "uid-3", Payment: models.Payment{Transaction: "tx-1"}})
+
+	// Замена существующего заказа в полном кэше ничего не вытесняет
+	c.Set("uid-3", &models.Order{OrderUID: "uid-3", Payment: models.Payment{Transaction: "tx-1"}})
+	for _, uid := range []string{"uid-1", "uid-2", "uid-3"} {
+		if _, ok := c.Get(uid); !ok {
+			t.Fatalf("Expected %s to stay cached after replace", uid)
+		}
+	}
+
+	// Заказы связаны общими ключами, поэтому вытесняются вместе: в кэше
+	// не остается части группы, по которой нашелся бы не самый новый заказ
+	c.Set("uid-4", &models.Order{OrderUID: "uid-4", TrackNumber: "TRACK-4"})
+	for _, uid := range []string{"uid-1", "uid-2", "uid-3"} {
+		if _, ok := c.Get(uid); ok {
+			t.Errorf("Expected %s to be evicted with its group", uid)
+		}
+	}
+	if _, ok := c.GetByTrackNumber("TRACK"); ok {
+		t.Error("Expected miss for evicted group")
+	}
+	if _, ok := c.GetByTrackNumber("TRACK-4"); !ok {
+		t.Error("Expected new order to be cached")
+	}
+}
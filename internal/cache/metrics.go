@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	cacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "Number of cache lookups that found an order.",
+	})
+
+	cacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "Number of cache lookups that did not find an order.",
+	})
+
+	cacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cache_evictions_total",
+		Help: "Number of orders evicted from the cache.",
+	})
+
+	cacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "cache_size",
+		Help: "Number of orders currently held in the cache.",
+	})
+)
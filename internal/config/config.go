@@ -1,32 +1,114 @@
 package config
 
 import (
-	"log"
-	"os"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+const maxConnectBackoff = 10 * time.Second
 
-func ConnectDB() {
-	dsn := os.Getenv("DB_URL")
+// OpenDB открывает пул соединений с PostgreSQL с параметрами из cfg, подключает
+// метрики и трассировку. Пока база не готова (например, docker-compose еще
+// поднимает контейнер), подключение повторяется с экспоненциальной задержкой.
+func OpenDB(ctx context.Context, cfg DatabaseConfig, log *slog.Logger) (*gorm.DB, error) {
+	connConfig, err := pgx.ParseConfig(cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("parse database dsn: %w", err)
+	}
+	if cfg.StatementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
 
-	if dsn == "" {
-		dsn = "host=localhost user=gegxkss password=postgres dbname=wbl0_db port=5432 sslmode=disable"
-		log.Println("DB_URL not set, using default:", dsn)
+	sqlDB := stdlib.OpenDB(*connConfig)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := ping(ctx, sqlDB, cfg, log); err != nil {
+		sqlDB.Close()
+		return nil, err
 	}
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		sqlDB.Close()
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+
+	if err := InstrumentDB(db); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("instrument database: %w", err)
+	}
+
+	return db, nil
+}
+
+func ping(ctx context.Context, sqlDB *sql.DB, cfg DatabaseConfig, log *slog.Logger) error {
+	attempts := max(cfg.ConnectAttempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = sqlDB.PingContext(ctx); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		wait := backoff(attempt, cfg.ConnectBackoff)
+		log.Warn("database not ready, retrying", "attempt", attempt, "max_attempts", attempts, "retry_in", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("connect to database: %w", ctx.Err())
+		case <-time.After(wait):
+		}
 	}
+	return fmt.Errorf("connect to database after %d attempts: %w", attempts, err)
+}
 
-	log.Println("Successfully connected to database")
+// backoff возвращает задержку перед следующей попыткой: base, 2*base, 4*base...
+// но не больше maxConnectBackoff.
+func backoff(attempt int, base time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempt && wait < maxConnectBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxConnectBackoff)
 }
 
-func RestoreFromDB() {
+// PoolStats — статистика пула соединений для /status.
+type PoolStats struct {
+	MaxOpen           int    `json:"max_open"`
+	Open              int    `json:"open"`
+	InUse             int    `json:"in_use"`
+	Idle              int    `json:"idle"`
+	WaitCount         int64  `json:"wait_count"`
+	WaitDuration      string `json:"wait_duration"`
+	MaxIdleClosed     int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64  `json:"max_lifetime_closed"`
+}
 
+func NewPoolStats(s sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpen:           s.MaxOpenConnections,
+		Open:              s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitDuration:      s.WaitDuration.String(),
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	}
 }
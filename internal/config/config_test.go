@@ -1,27 +1,103 @@
 package config
 
 import (
-	"os"
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
-func TestConnectDB_DefaultDSN(t *testing.T) {
-	os.Unsetenv("DB_URL")
-	defer func() {
-		if r := recover(); r != nil {
-			// Ожидаем фатальный лог, тест пройден
+func TestOpenDB_RetriesThenFails(t *testing.T) {
+	cfg := Default().Database
+	cfg.DSN = "host=127.0.0.1 port=1 user=u dbname=db sslmode=disable connect_timeout=1"
+	cfg.ConnectAttempts = 2
+	cfg.ConnectBackoff = time.Millisecond
+
+	db, err := OpenDB(context.Background(), cfg, logger.Nop())
+	if err == nil {
+		t.Fatalf("Expected error for unreachable database, got %v", db)
+	}
+	if !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("Expected retry count in error, got %v", err)
+	}
+}
+
+func TestOpenDB_StopsOnContextCancel(t *testing.T) {
+	cfg := Default().Database
+	cfg.DSN = "host=127.0.0.1 port=1 user=u dbname=db sslmode=disable connect_timeout=1"
+	cfg.ConnectBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := OpenDB(ctx, cfg, logger.Nop()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context deadline error, got %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base := 500 * time.Millisecond
+	cases := map[int]time.Duration{
+		1:  500 * time.Millisecond,
+		2:  time.Second,
+		3:  2 * time.Second,
+		10: maxConnectBackoff,
+	}
+	for attempt, want := range cases {
+		if got := backoff(attempt, base); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
 		}
-	}()
-	ConnectDB() // если не упадет, тест не пройден
+	}
 }
 
-func TestConnectDB_WithEnv(t *testing.T) {
-	os.Setenv("DB_URL", "host=localhost user=gegxkss password=postgres dbname=wbl0_db port=5432 sslmode=disable")
-	defer os.Unsetenv("DB_URL")
-	defer func() {
-		if r := recover(); r != nil {
-			// Ожидаем фатальный лог, тест пройден
+func TestInstrumentDB(t *testing.T) {
+	db, err := gorm.Open(postgres.Open("host=localhost dbname=test"), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := InstrumentDB(db); err != nil {
+		t.Fatalf("InstrumentDB failed: %v", err)
+	}
+	if db.Callback().Query().Get("metrics:after_query") == nil {
+		t.Error("Query callback not registered")
+	}
+}
+
+func TestInstrumentDB_ReplacesPoolStats(t *testing.T) {
+	for _, maxOpen := range []int{3, 7} {
+		db, err := gorm.Open(postgres.Open("host=localhost dbname=test"), &gorm.Config{DisableAutomaticPing: true})
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatalf("DB failed: %v", err)
+		}
+		sqlDB.SetMaxOpenConns(maxOpen)
+		if err := InstrumentDB(db); err != nil {
+			t.Fatalf("InstrumentDB failed: %v", err)
+		}
+	}
+
+	// Метрики пула должны описывать последний пул
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() != "go_sql_max_open_connections" {
+			continue
+		}
+		if got := f.GetMetric()[0].GetGauge().GetValue(); got != 7 {
+			t.Errorf("Expected max open connections 7, got %v", got)
 		}
-	}()
-	ConnectDB()
+		return
+	}
+	t.Error("Pool stats collector not registered")
 }
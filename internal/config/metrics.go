@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+const queryStartKey = "metrics:query_start"
+
+var queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "db_query_duration_seconds",
+	Help:    "GORM query latency by operation and table.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"operation", "table"})
+
+// poolStats — сборщик статистики пула, зарегистрированный последним
+// вызовом InstrumentDB.
+var poolStats struct {
+	mu        sync.Mutex
+	collector prometheus.Collector
+}
+
+// InstrumentDB вешает на GORM колбэки для метрик и трассировки запросов
+// и регистрирует сборщик статистики пула соединений. Сборщик предыдущего
+// пула снимается с регистрации, чтобы метрики описывали текущий пул.
+func InstrumentDB(db *gorm.DB) error {
+	cb := db.Callback()
+	err := errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", startQuery),
+		cb.Create().After("gorm:create").Register("metrics:after_create", finishQuery("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", startQuery),
+		cb.Query().After("gorm:query").Register("metrics:after_query", finishQuery("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", startQuery),
+		cb.Update().After("gorm:update").Register("metrics:after_update", finishQuery("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", startQuery),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", finishQuery("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", startQuery),
+		cb.Row().After("gorm:row").Register("metrics:after_row", finishQuery("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", startQuery),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", finishQuery("raw")),
+		traceDB(db),
+	)
+	if err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return registerPoolStats(collectors.NewDBStatsCollector(sqlDB, "postgres"))
+}
+
+func registerPoolStats(c prometheus.Collector) error {
+	poolStats.mu.Lock()
+	defer poolStats.mu.Unlock()
+
+	if poolStats.collector != nil {
+		prometheus.Unregister(poolStats.collector)
+	}
+	if err := prometheus.Register(c); err != nil {
+		poolStats.collector = nil
+		return err
+	}
+	poolStats.collector = c
+	return nil
+}
+
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func finishQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		started, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		queryDuration.WithLabelValues(operation, table).Observe(time.Since(started).Seconds())
+	}
+}
@@ -0,0 +1,367 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	HTTP     HTTPConfig     `yaml:"http" json:"http"`
+	GRPC     GRPCConfig     `yaml:"grpc" json:"grpc"`
+	Kafka    KafkaConfig    `yaml:"kafka" json:"kafka"`
+	Database DatabaseConfig `yaml:"database" json:"database"`
+	Cache    CacheConfig    `yaml:"cache" json:"cache"`
+	Log      LogConfig      `yaml:"log" json:"log"`
+	Tracing  TracingConfig  `yaml:"tracing" json:"tracing"`
+	// ShutdownTimeout ограничивает время graceful shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" json:"shutdown_timeout"`
+}
+
+type HTTPConfig struct {
+	Addr string `yaml:"addr" json:"addr"`
+}
+
+type GRPCConfig struct {
+	Addr string `yaml:"addr" json:"addr"`
+}
+
+type KafkaConfig struct {
+	Brokers []string `yaml:"brokers" json:"brokers"`
+	Topic   string   `yaml:"topic" json:"topic"`
+	GroupID string   `yaml:"group_id" json:"group_id"`
+}
+
+type DatabaseConfig struct {
+	DSN              string        `yaml:"dsn" json:"dsn"`
+	MaxOpenConns     int           `yaml:"max_open_conns" json:"max_open_conns"`
+	MaxIdleConns     int           `yaml:"max_idle_conns" json:"max_idle_conns"`
+	ConnMaxLifetime  time.Duration `yaml:"conn_max_lifetime" json:"conn_max_lifetime"`
+	ConnMaxIdleTime  time.Duration `yaml:"conn_max_idle_time" json:"conn_max_idle_time"`
+	StatementTimeout time.Duration `yaml:"statement_timeout" json:"statement_timeout"`
+	// ConnectAttempts — сколько раз пытаться подключиться при старте.
+	ConnectAttempts int           `yaml:"connect_attempts" json:"connect_attempts"`
+	ConnectBackoff  time.Duration `yaml:"connect_backoff" json:"connect_backoff"`
+}
+
+type CacheConfig struct {
+	Size int `yaml:"size" json:"size"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" json:"level"`
+}
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" json:"exporter"`
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+}
+
+// Default возвращает конфигурацию для локального запуска через docker-compose.
+func Default() Config {
+	return Config{
+		HTTP: HTTPConfig{Addr: ":8081"},
+		GRPC: GRPCConfig{Addr: ":9090"},
+		Kafka: KafkaConfig{
+			Brokers: []string{"localhost:9091", "localhost:9092", "localhost:9093"},
+			Topic:   "order",
+			GroupID: "orders-group",
+		},
+		Database: DatabaseConfig{
+			DSN:              "host=localhost user=gegxkss password=postgres dbname=wbl0_db port=5432 sslmode=disable",
+			MaxOpenConns:     25,
+			MaxIdleConns:     10,
+			ConnMaxLifetime:  30 * time.Minute,
+			ConnMaxIdleTime:  5 * time.Minute,
+			StatementTimeout: 10 * time.Second,
+			ConnectAttempts:  10,
+			ConnectBackoff:   500 * time.Millisecond,
+		},
+		Cache:           CacheConfig{Size: 1000},
+		Log:             LogConfig{Level: "info"},
+		Tracing:         TracingConfig{Exporter: tracing.ExporterNone},
+		ShutdownTimeout: 15 * time.Second,
+	}
+}
+
+// Load собирает конфигурацию с приоритетом: флаги > переменные окружения >
+// YAML файл > значения по умолчанию. Флаги регистрируются в fs, поэтому
+// вызывающий код может добавить в него свои флаги до вызова Load.
+// Путь к файлу берется из флага -config или переменной CONFIG_FILE.
+func Load(fs *flag.FlagSet, args []string, getenv func(string) string) (Config, error) {
+	path := fs.String("config", "", "path to YAML config file")
+	fs.String("http-addr", "", "HTTP listen address")
+	fs.String("grpc-addr", "", "gRPC listen address")
+	fs.String("kafka-brokers", "", "comma separated Kafka brokers")
+	fs.String("kafka-topic", "", "Kafka topic with orders")
+	fs.String("kafka-group-id", "", "Kafka consumer group ID")
+	fs.String("db-dsn", "", "PostgreSQL DSN")
+	fs.String("db-max-open-conns", "", "maximum open database connections")
+	fs.String("db-max-idle-conns", "", "maximum idle database connections")
+	fs.String("db-conn-max-lifetime", "", "maximum database connection lifetime")
+	fs.String("db-conn-max-idle-time", "", "maximum database connection idle time")
+	fs.String("db-statement-timeout", "", "PostgreSQL statement_timeout, 0 disables")
+	fs.String("db-connect-attempts", "", "database connection attempts at startup")
+	fs.String("db-connect-backoff", "", "initial delay between database connection attempts")
+	fs.String("cache-size", "", "maximum number of orders in cache")
+	fs.String("log-level", "", "log level: debug, info, warn, error")
+	fs.String("tracing-exporter", "", "trace exporter: none, stdout, file, otlp")
+	fs.String("tracing-endpoint", "", "trace file path or OTLP endpoint")
+	fs.String("shutdown-timeout", "", "graceful shutdown timeout")
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+
+	if *path == "" {
+		*path = getenv("CONFIG_FILE")
+	}
+	if *path != "" {
+		if err := cfg.loadFile(*path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for key, name := range envKeys {
+		if value := getenv(key); value != "" {
+			if err := cfg.set(name, value); err != nil {
+				return Config{}, fmt.Errorf("env %s: %w", key, err)
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "config" || flagErr != nil {
+			return
+		}
+		if _, known := settable[f.Name]; !known {
+			return
+		}
+		if err := cfg.set(f.Name, f.Value.String()); err != nil {
+			flagErr = fmt.Errorf("flag -%s: %w", f.Name, err)
+		}
+	})
+	if flagErr != nil {
+		return Config{}, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// envKeys сопоставляет переменные окружения с именами флагов.
+var envKeys = map[string]string{
+	"HTTP_ADDR":             "http-addr",
+	"GRPC_ADDR":             "grpc-addr",
+	"KAFKA_BROKERS":         "kafka-brokers",
+	"KAFKA_TOPIC":           "kafka-topic",
+	"KAFKA_GROUP_ID":        "kafka-group-id",
+	"DB_URL":                "db-dsn",
+	"DB_MAX_OPEN_CONNS":     "db-max-open-conns",
+	"DB_MAX_IDLE_CONNS":     "db-max-idle-conns",
+	"DB_CONN_MAX_LIFETIME":  "db-conn-max-lifetime",
+	"DB_CONN_MAX_IDLE_TIME": "db-conn-max-idle-time",
+	"DB_STATEMENT_TIMEOUT":  "db-statement-timeout",
+	"DB_CONNECT_ATTEMPTS":   "db-connect-attempts",
+	"DB_CONNECT_BACKOFF":    "db-connect-backoff",
+	"CACHE_SIZE":            "cache-size",
+	"LOG_LEVEL":             "log-level",
+	"TRACING_EXPORTER":      "tracing-exporter",
+	"TRACING_ENDPOINT":      "tracing-endpoint",
+	"SHUTDOWN_TIMEOUT":      "shutdown-timeout",
+}
+
+var settable = map[string]struct{}{}
+
+func init() {
+	for _, name := range envKeys {
+		settable[name] = struct{}{}
+	}
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) set(name, value string) error {
+	switch name {
+	case "http-addr":
+		c.HTTP.Addr = value
+	case "grpc-addr":
+		c.GRPC.Addr = value
+	case "kafka-brokers":
+		c.Kafka.Brokers = splitList(value)
+	case "kafka-topic":
+		c.Kafka.Topic = value
+	case "kafka-group-id":
+		c.Kafka.GroupID = value
+	case "db-dsn":
+		c.Database.DSN = value
+	case "db-max-open-conns":
+		return setInt(&c.Database.MaxOpenConns, value)
+	case "db-max-idle-conns":
+		return setInt(&c.Database.MaxIdleConns, value)
+	case "db-conn-max-lifetime":
+		return setDuration(&c.Database.ConnMaxLifetime, value)
+	case "db-conn-max-idle-time":
+		return setDuration(&c.Database.ConnMaxIdleTime, value)
+	case "db-statement-timeout":
+		return setDuration(&c.Database.StatementTimeout, value)
+	case "db-connect-attempts":
+		return setInt(&c.Database.ConnectAttempts, value)
+	case "db-connect-backoff":
+		return setDuration(&c.Database.ConnectBackoff, value)
+	case "cache-size":
+		return setInt(&c.Cache.Size, value)
+	case "log-level":
+		c.Log.Level = value
+	case "tracing-exporter":
+		c.Tracing.Exporter = value
+	case "tracing-endpoint":
+		c.Tracing.Endpoint = value
+	case "shutdown-timeout":
+		return setDuration(&c.ShutdownTimeout, value)
+	default:
+		return fmt.Errorf("unknown setting %s", name)
+	}
+	return nil
+}
+
+func setInt(dst *int, value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", value)
+	}
+	*dst = n
+	return nil
+}
+
+func setDuration(dst *time.Duration, value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", value)
+	}
+	*dst = d
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Validate проверяет, что конфигурации достаточно для запуска сервиса.
+func (c Config) Validate() error {
+	var errs []error
+	if c.HTTP.Addr == "" {
+		errs = append(errs, errors.New("http.addr is required"))
+	}
+	if c.GRPC.Addr == "" {
+		errs = append(errs, errors.New("grpc.addr is required"))
+	}
+	if len(c.Kafka.Brokers) == 0 {
+		errs = append(errs, errors.New("kafka.brokers is required"))
+	}
+	if c.Kafka.Topic == "" {
+		errs = append(errs, errors.New("kafka.topic is required"))
+	}
+	if c.Kafka.GroupID == "" {
+		errs = append(errs, errors.New("kafka.group_id is required"))
+	}
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("database.dsn is required"))
+	}
+	if c.Database.MaxOpenConns <= 0 {
+		errs = append(errs, fmt.Errorf("database.max_open_conns must be positive, got %d", c.Database.MaxOpenConns))
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("database.max_idle_conns must be between 0 and max_open_conns, got %d", c.Database.MaxIdleConns))
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 || c.Database.StatementTimeout < 0 {
+		errs = append(errs, errors.New("database durations must not be negative"))
+	}
+	if c.Database.ConnectAttempts <= 0 {
+		errs = append(errs, fmt.Errorf("database.connect_attempts must be positive, got %d", c.Database.ConnectAttempts))
+	}
+	if c.Database.ConnectBackoff <= 0 {
+		errs = append(errs, fmt.Errorf("database.connect_backoff must be positive, got %s", c.Database.ConnectBackoff))
+	}
+	if c.Cache.Size <= 0 {
+		errs = append(errs, fmt.Errorf("cache.size must be positive, got %d", c.Cache.Size))
+	}
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	switch strings.ToLower(c.Tracing.Exporter) {
+	case "", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	case tracing.ExporterFile:
+		if c.Tracing.Endpoint == "" {
+			errs = append(errs, errors.New("tracing.endpoint is required for file exporter"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter: unknown exporter %q", c.Tracing.Exporter))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown_timeout must be positive, got %s", c.ShutdownTimeout))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+var dsnPassword = regexp.MustCompile(`(?i)(password=)('[^']*'|\S+)`)
+
+// Redacted возвращает копию конфигурации, безопасную для вывода в лог.
+func (c Config) Redacted() Config {
+	c.Kafka.Brokers = append([]string(nil), c.Kafka.Brokers...)
+	c.Database.DSN = redactDSN(c.Database.DSN)
+	return c
+}
+
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			}
+		}
+		q := u.Query()
+		if q.Has("password") {
+			q.Set("password", "xxxxx")
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}xxxxx")
+}
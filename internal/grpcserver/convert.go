@@ -0,0 +1,82 @@
+package grpcserver
+
+import (
+	"time"
+
+	ordersv1 "github.com/gegxkss/wbL0/api/orders/v1"
+	"github.com/gegxkss/wbL0/internal/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProtoOrder(o *models.Order) *ordersv1.Order {
+	items := make([]*ordersv1.Item, 0, len(o.Items))
+	for _, item := range o.Items {
+		items = append(items, &ordersv1.Item{
+			ChrtId:      int64(item.ChrtId),
+			TrackNumber: item.Tracknumber,
+			Price:       toProtoMoney(item.Price),
+			Rid:         item.Rid,
+			Name:        item.Name,
+			Sale:        int32(item.Sale),
+			Size:        item.Size,
+			TotalPrice:  toProtoMoney(item.TotalPrice),
+			NmId:        int64(item.NmId),
+			Brand:       item.Brand,
+			Status:      int32(item.Status),
+		})
+	}
+	return &ordersv1.Order{
+		OrderUid:          o.OrderUID,
+		TrackNumber:       o.TrackNumber,
+		Entry:             o.Entry,
+		Locale:            o.Locale,
+		InternalSignature: o.InternalSignature,
+		CustomerId:        o.CustomerId,
+		DeliveryService:   o.DeliveryService,
+		Shardkey:          o.ShardKey,
+		SmId:              int64(o.SmId),
+		DateCreated:       toProtoTime(o.DateCreated),
+		OofShard:          o.OofShard,
+		Delivery: &ordersv1.Delivery{
+			Name:    o.Delivery.Name,
+			Phone:   o.Delivery.Phone,
+			Zip:     o.Delivery.Zip,
+			City:    o.Delivery.City,
+			Address: o.Delivery.Address,
+			Region:  o.Delivery.Region,
+			Email:   o.Delivery.Email,
+		},
+		Payment: &ordersv1.Payment{
+			Transaction:  o.Payment.Transaction,
+			RequestId:    o.Payment.RequestID,
+			Currency:     o.Payment.Currency,
+			Provider:     o.Payment.Provider,
+			Amount:       toProtoMoney(o.Payment.Amount),
+			PaymentDt:    toProtoTime(o.Payment.PaymentDt),
+			Bank:         o.Payment.Bank,
+			DeliveryCost: toProtoMoney(o.Payment.DeliveryCost),
+			GoodsTotal:   toProtoMoney(o.Payment.GoodsTotal),
+			CustomFee:    toProtoMoney(o.Payment.CustomFee),
+		},
+		Items: items,
+	}
+}
+
+func toProtoMoney(m models.Money) *ordersv1.Money {
+	return &ordersv1.Money{Amount: m.Amount, Currency: m.Currency}
+}
+
+// toProtoTime оставляет незаполненное время пустым полем, а не 0001-01-01.
+func toProtoTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromProtoTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
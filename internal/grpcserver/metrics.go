@@ -0,0 +1,45 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_requests_total",
+		Help: "Number of gRPC requests by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_request_duration_seconds",
+		Help:    "gRPC request latency by method and status code; for streams, the stream lifetime.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+func observe(method string, started time.Time, err error) {
+	labels := prometheus.Labels{"method": method, "code": status.Code(err).String()}
+	grpcRequests.With(labels).Inc()
+	grpcDuration.With(labels).Observe(time.Since(started).Seconds())
+}
+
+// unaryMetrics считает запросы по полному имени метода, как instrument в HTTP.
+func unaryMetrics(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	started := time.Now()
+	resp, err := handler(ctx, req)
+	observe(info.FullMethod, started, err)
+	return resp, err
+}
+
+func streamMetrics(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	started := time.Now()
+	err := handler(srv, ss)
+	observe(info.FullMethod, started, err)
+	return err
+}
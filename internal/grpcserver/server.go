@@ -0,0 +1,201 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+
+	ordersv1 "github.com/gegxkss/wbL0/api/orders/v1"
+	"github.com/gegxkss/wbL0/internal/broadcast"
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/orders"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Server реализует orders.v1.OrderService поверх того же кэша и репозитория,
+// что и HTTP обработчики.
+type Server struct {
+	ordersv1.UnimplementedOrderServiceServer
+
+	cache       *cache.Cache
+	repo        repository.OrderRepository
+	broadcaster *broadcast.Broadcaster
+	log         *slog.Logger
+
+	grpc *grpc.Server
+	// stopping закрывается в Shutdown, чтобы WatchOrders завершились
+	// и GracefulStop не ждал их бесконечно.
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+func New(cache *cache.Cache, repo repository.OrderRepository, broadcaster *broadcast.Broadcaster, log *slog.Logger) *Server {
+	s := &Server{
+		cache:       cache,
+		repo:        repo,
+		broadcaster: broadcaster,
+		log:         log.With("component", "grpc"),
+		stopping:    make(chan struct{}),
+	}
+	s.grpc = grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryMetrics),
+		grpc.ChainStreamInterceptor(streamMetrics),
+	)
+	ordersv1.RegisterOrderServiceServer(s.grpc, s)
+	reflection.Register(s.grpc)
+	return s
+}
+
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// Shutdown завершает подписки WatchOrders и дожидается текущих запросов.
+// Если ctx истекает раньше, соединения закрываются сразу.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stopping) })
+
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		return fmt.Errorf("wait for grpc requests: %w", ctx.Err())
+	}
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *ordersv1.GetOrderRequest) (*ordersv1.Order, error) {
+	if req.GetOrderUid() == "" {
+		return nil, status.Error(codes.InvalidArgument, "order_uid is required")
+	}
+	if cached, ok := s.cache.Get(req.GetOrderUid()); ok {
+		if order, ok := cached.(*models.Order); ok {
+			return toProtoOrder(order), nil
+		}
+	}
+
+	order, err := s.repo.Get(ctx, req.GetOrderUid())
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+	if err != nil {
+		s.log.Error("get order failed", "order_uid", req.GetOrderUid(), "error", err)
+		return nil, status.Error(codes.Internal, "get order failed")
+	}
+	s.cache.Set(order.OrderUID, order)
+	return toProtoOrder(order), nil
+}
+
+func (s *Server) ListOrders(ctx context.Context, req *ordersv1.ListOrdersRequest) (*ordersv1.ListOrdersResponse, error) {
+	if req.GetPageSize() < 0 || req.GetPageSize() > repository.MaxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 0 and %d", repository.MaxPageSize)
+	}
+	q := repository.OrderQuery{
+		Filter: repository.OrderFilter{
+			CustomerID:      req.GetCustomerId(),
+			TrackNumber:     req.GetTrackNumber(),
+			DeliveryService: req.GetDeliveryService(),
+			Locale:          req.GetLocale(),
+			Brand:           req.GetBrand(),
+			CreatedFrom:     fromProtoTime(req.GetCreatedFrom()),
+			CreatedTo:       fromProtoTime(req.GetCreatedTo()),
+		},
+		Limit:     int(req.GetPageSize()),
+		Cursor:    req.GetPageToken(),
+		WithTotal: req.GetIncludeTotal(),
+	}
+	if err := q.Filter.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	page, err := s.repo.Find(ctx, q)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+	if err != nil {
+		s.log.Error("list orders failed", "error", err)
+		return nil, status.Error(codes.Internal, "list orders failed")
+	}
+
+	resp := &ordersv1.ListOrdersResponse{
+		Orders:        make([]*ordersv1.Order, 0, len(page.Orders)),
+		NextPageToken: page.NextCursor,
+		Total:         page.Total,
+	}
+	for i := range page.Orders {
+		resp.Orders = append(resp.Orders, toProtoOrder(&page.Orders[i]))
+	}
+	return resp, nil
+}
+
+// BatchGetOrders работает как POST /api/v1/orders:batchGet: найденные в кэше
+// заказы отдаются сразу, остальные загружаются одним запросом к базе.
+func (s *Server) BatchGetOrders(ctx context.Context, req *ordersv1.BatchGetOrdersRequest) (*ordersv1.BatchGetOrdersResponse, error) {
+	uids, err := orders.BatchIDs(req.GetOrderUids())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	batch, err := orders.BatchGet(ctx, s.cache, s.repo, uids)
+	if err != nil {
+		s.log.Error("batch get orders failed", "error", err)
+		return nil, status.Error(codes.Internal, "batch get orders failed")
+	}
+
+	resp := &ordersv1.BatchGetOrdersResponse{Orders: make([]*ordersv1.Order, 0, len(batch.Orders)), Missing: batch.Missing}
+	for _, order := range batch.Orders {
+		resp.Orders = append(resp.Orders, toProtoOrder(order))
+	}
+	return resp, nil
+}
+
+// WatchOrders отправляет заказы, сохраненные после подписки. Клиент, который
+// не успевает читать поток, отключается с RESOURCE_EXHAUSTED и должен
+// переподключиться, догрузив пропущенное через ListOrders.
+func (s *Server) WatchOrders(req *ordersv1.WatchOrdersRequest, stream grpc.ServerStreamingServer[ordersv1.Order]) error {
+	sub := s.broadcaster.Subscribe(broadcast.Filter{
+		CustomerID:      req.GetCustomerId(),
+		DeliveryService: req.GetDeliveryService(),
+	})
+	defer sub.Close()
+
+	log := s.log.With("customer_id", req.GetCustomerId(), "delivery_service", req.GetDeliveryService())
+	log.Debug("watch started")
+	defer log.Debug("watch finished")
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case event, ok := <-sub.Events():
+			if !ok {
+				if sub.Dropped() {
+					log.Warn("slow watcher disconnected")
+					return status.Error(codes.ResourceExhausted, "client is too slow, reconnect")
+				}
+				// Рассылка остановлена вместе с сервисом
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+			if err := stream.Send(toProtoOrder(event.Order)); err != nil {
+				return err
+			}
+		}
+	}
+}
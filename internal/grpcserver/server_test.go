@@ -0,0 +1,230 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	ordersv1 "github.com/gegxkss/wbL0/api/orders/v1"
+	"github.com/gegxkss/wbL0/internal/broadcast"
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/orders"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type testEnv struct {
+	server      *Server
+	client      ordersv1.OrderServiceClient
+	repo        *repository.Memory
+	cache       *cache.Cache
+	broadcaster *broadcast.Broadcaster
+}
+
+// newTestEnv запускает сервер на bufconn и подключает к нему клиента.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	env := &testEnv{
+		repo:        repository.NewMemory(),
+		cache:       cache.NewCache(logger.Nop()),
+		broadcaster: broadcast.New(broadcast.DefaultBuffer, logger.Nop()),
+	}
+	env.server = New(env.cache, env.repo, env.broadcaster, logger.Nop())
+
+	lis := bufconn.Listen(1 << 20)
+	go env.server.Serve(lis)
+	t.Cleanup(func() { env.server.grpc.Stop() })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	env.client = ordersv1.NewOrderServiceClient(conn)
+	return env
+}
+
+func testOrder(uid, customer string, created time.Time) *models.Order {
+	return &models.Order{
+		OrderUID:    uid,
+		CustomerId:  customer,
+		DateCreated: created,
+		Delivery:    models.Delivery{Name: "Test Testov"},
+		Payment:     models.Payment{Currency: "USD", Amount: models.NewMoney(1500, "USD")},
+		Items:       []models.Items{{Name: "item", Brand: "Vivienne Sabo", Price: models.NewMoney(1500, "USD")}},
+	}
+}
+
+func (env *testEnv) save(t *testing.T, orders ...*models.Order) {
+	t.Helper()
+	for _, order := range orders {
+		if err := env.repo.Save(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	env := newTestEnv(t)
+	env.save(t, testOrder("uid-1", "customer", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	ctx := context.Background()
+
+	order, err := env.client.GetOrder(ctx, &ordersv1.GetOrderRequest{OrderUid: "uid-1"})
+	if err != nil {
+		t.Fatalf("GetOrder failed: %v", err)
+	}
+	if order.GetDelivery().GetName() != "Test Testov" || order.GetPayment().GetAmount().GetAmount() != 1500 {
+		t.Errorf("Unexpected order: %v", order)
+	}
+	if !order.GetDateCreated().AsTime().Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected date_created: %v", order.GetDateCreated())
+	}
+	if order.GetPayment().GetPaymentDt() != nil {
+		t.Errorf("Expected empty payment_dt, got %v", order.GetPayment().GetPaymentDt())
+	}
+	if _, ok := env.cache.Get("uid-1"); !ok {
+		t.Error("Order loaded from repository was not cached")
+	}
+
+	_, err = env.client.GetOrder(ctx, &ordersv1.GetOrderRequest{OrderUid: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+	_, err = env.client.GetOrder(ctx, &ordersv1.GetOrderRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestListOrders(t *testing.T) {
+	env := newTestEnv(t)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	env.save(t,
+		testOrder("uid-1", "alice", base),
+		testOrder("uid-2", "alice", base.Add(time.Hour)),
+		testOrder("uid-3", "bob", base.Add(2*time.Hour)),
+	)
+	ctx := context.Background()
+
+	resp, err := env.client.ListOrders(ctx, &ordersv1.ListOrdersRequest{CustomerId: "alice", PageSize: 1, IncludeTotal: true})
+	if err != nil {
+		t.Fatalf("ListOrders failed: %v", err)
+	}
+	if len(resp.GetOrders()) != 1 || resp.GetOrders()[0].GetOrderUid() != "uid-2" {
+		t.Fatalf("Expected uid-2 first, got %v", resp.GetOrders())
+	}
+	if resp.Total == nil || resp.GetTotal() != 2 {
+		t.Errorf("Expected total 2, got %v", resp.Total)
+	}
+	resp, err = env.client.ListOrders(ctx, &ordersv1.ListOrdersRequest{CustomerId: "alice", PageSize: 1, PageToken: resp.GetNextPageToken()})
+	if err != nil {
+		t.Fatalf("ListOrders failed: %v", err)
+	}
+	if len(resp.GetOrders()) != 1 || resp.GetOrders()[0].GetOrderUid() != "uid-1" || resp.GetNextPageToken() != "" {
+		t.Errorf("Unexpected second page: %v", resp)
+	}
+	if resp.Total != nil {
+		t.Errorf("Expected no total, got %d", resp.GetTotal())
+	}
+
+	for name, req := range map[string]*ordersv1.ListOrdersRequest{
+		"page size": {PageSize: repository.MaxPageSize + 1},
+		"token":     {PageToken: "broken"},
+		"created":   {CreatedFrom: timestamppb.New(base), CreatedTo: timestamppb.New(base)},
+	} {
+		if _, err := env.client.ListOrders(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: expected InvalidArgument, got %v", name, err)
+		}
+	}
+}
+
+func TestBatchGetOrders(t *testing.T) {
+	env := newTestEnv(t)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	env.save(t, testOrder("uid-1", "alice", base))
+	env.cache.Set("uid-2", testOrder("uid-2", "bob", base))
+	ctx := context.Background()
+
+	resp, err := env.client.BatchGetOrders(ctx, &ordersv1.BatchGetOrdersRequest{
+		OrderUids: []string{"uid-2", "missing", "uid-1", "uid-2", ""},
+	})
+	if err != nil {
+		t.Fatalf("BatchGetOrders failed: %v", err)
+	}
+	if len(resp.GetOrders()) != 2 || resp.GetOrders()[0].GetOrderUid() != "uid-2" || resp.GetOrders()[1].GetOrderUid() != "uid-1" {
+		t.Errorf("Expected uid-2 and uid-1 in request order, got %v", resp.GetOrders())
+	}
+	if len(resp.GetMissing()) != 1 || resp.GetMissing()[0] != "missing" {
+		t.Errorf("Expected missing [missing], got %v", resp.GetMissing())
+	}
+
+	_, err = env.client.BatchGetOrders(ctx, &ordersv1.BatchGetOrdersRequest{OrderUids: make([]string, orders.MaxBatchSize+1)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for too many order_uids, got %v", err)
+	}
+	_, err = env.client.BatchGetOrders(ctx, &ordersv1.BatchGetOrdersRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for empty request, got %v", err)
+	}
+}
+
+func TestWatchOrders(t *testing.T) {
+	env := newTestEnv(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := env.client.WatchOrders(ctx, &ordersv1.WatchOrdersRequest{CustomerId: "alice"})
+	if err != nil {
+		t.Fatalf("WatchOrders failed: %v", err)
+	}
+
+	// Подписка оформляется на сервере асинхронно, поэтому заказы
+	// публикуются, пока клиент не получит первый.
+	received := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-received:
+				return
+			case <-ticker.C:
+				env.broadcaster.Publish(testOrder("bob-order", "bob", time.Now()))
+				env.broadcaster.Publish(testOrder("alice-order", "alice", time.Now()))
+			}
+		}
+	}()
+
+	order, err := stream.Recv()
+	close(received)
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if order.GetOrderUid() != "alice-order" {
+		t.Errorf("Expected only alice's orders, got %q", order.GetOrderUid())
+	}
+
+	// При остановке сервера поток завершается с UNAVAILABLE
+	if err := env.server.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable after shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/orders"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxBatchBody — предельный размер тела запроса batchGet.
+const maxBatchBody = 1 << 20
+
+var errInvalidBody = errors.New("invalid request body")
+
+type batchGetRequest struct {
+	OrderUIDs []string `json:"order_uids"`
+}
+
+type batchGetResponse struct {
+	Orders  []*models.Order `json:"orders"`
+	Missing []string        `json:"missing"`
+}
+
+// parseBatchGet читает список order_uid, убирая пустые значения и повторы
+// с сохранением порядка.
+func parseBatchGet(body io.Reader) ([]string, error) {
+	var req batchGetRequest
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		return nil, errInvalidBody
+	}
+	return orders.BatchIDs(req.OrderUIDs)
+}
+
+// batchGetOrders отдает заказы по списку order_uid: найденные в кэше сразу,
+// остальные одним запросом к базе. Заказы возвращаются в порядке запроса,
+// ненайденные order_uid перечисляются в missing.
+func batchGetOrders(ctx context.Context, w http.ResponseWriter, r *http.Request, cache *cache.Cache, repo repository.OrderRepository, log *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "handlers.batchGetOrders",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPRoute(apiPrefix+"/orders:batchGet")),
+	)
+	defer span.End()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+	uids, err := parseBatchGet(http.MaxBytesReader(w, r.Body, maxBatchBody))
+	if err != nil {
+		code := CodeInvalidParameter
+		if errors.Is(err, errInvalidBody) {
+			code = CodeInvalidRequestBody
+		}
+		writeProblem(w, http.StatusBadRequest, code, err.Error())
+		return
+	}
+
+	batch, err := orders.BatchGet(ctx, cache, repo, uids)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Error("batch get orders failed", "error", err)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
+		return
+	}
+	span.SetAttributes(
+		attribute.Int("orders.requested", len(uids)),
+		attribute.Int("cache.hits", len(uids)-batch.CacheMisses),
+	)
+
+	log.Debug("batch get orders", "requested", len(uids), "cache_misses", batch.CacheMisses, "missing", len(batch.Missing))
+	json.NewEncoder(w).Encode(batchGetResponse{Orders: batch.Orders, Missing: batch.Missing})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/orders"
+	"github.com/gegxkss/wbL0/internal/repository"
+)
+
+func TestBatchGetOrders(t *testing.T) {
+	repo := repository.NewMemory()
+	c := cache.NewCache(logger.Nop())
+	if err := repo.Save(context.Background(), &models.Order{OrderUID: "db"}); err != nil {
+		t.Fatal(err)
+	}
+	c.Set("cached", &models.Order{OrderUID: "cached", TrackNumber: "FROM-CACHE"})
+	mux := newTestMux(c, repo)
+
+	body := `{"order_uids": ["missing", "cached", "db", "cached", ""]}`
+	req := httptest.NewRequest("POST", "/api/v1/orders:batchGet", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp batchGetResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(resp.Orders) != 2 || resp.Orders[0].OrderUID != "cached" || resp.Orders[1].OrderUID != "db" {
+		t.Fatalf("Expected cached and db orders, got %+v", resp.Orders)
+	}
+	if resp.Orders[0].TrackNumber != "FROM-CACHE" {
+		t.Errorf("Expected cached order to be served from cache, got %+v", resp.Orders[0])
+	}
+	if len(resp.Missing) != 1 || resp.Missing[0] != "missing" {
+		t.Errorf("Expected missing [missing], got %v", resp.Missing)
+	}
+	if _, ok := c.Get("db"); !ok {
+		t.Error("Expected order loaded from database to be cached")
+	}
+}
+
+func TestBatchGetOrders_Invalid(t *testing.T) {
+	mux := newTestMux(cache.NewCache(logger.Nop()), repository.NewMemory())
+
+	cases := map[string]string{
+		"not json": `order_uids`,
+		"empty":    `{"order_uids": []}`,
+		"too many": `{"order_uids": [` + strings.Repeat(`"x",`, orders.MaxBatchSize) + `"last"]}`,
+	}
+	for name, body := range cases {
+		req := httptest.NewRequest("POST", "/api/v1/orders:batchGet", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/orders:batchGet", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "POST" {
+		t.Errorf("Expected 405 with Allow: POST, got %d", w.Code)
+	}
+}
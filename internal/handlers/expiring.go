@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"container/list"
+	"time"
+)
+
+// pruneInterval — как часто удаляются истекшие записи.
+const pruneInterval = time.Minute
+
+type expiringItem[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// expiringMap хранит значения с общим сроком жизни не больше limit штук.
+// Записи лежат в порядке добавления, он же порядок истечения, поэтому
+// очистка и вытеснение при переполнении начинаются с самых старых и не
+// перебирают всю карту. Синхронизацию обеспечивает владелец.
+type expiringMap[V any] struct {
+	ttl    time.Duration
+	limit  int
+	order  *list.List
+	items  map[string]*list.Element
+	pruned time.Time
+}
+
+func newExpiringMap[V any](ttl time.Duration, limit int) *expiringMap[V] {
+	return &expiringMap[V]{
+		ttl:   ttl,
+		limit: limit,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// get возвращает значение, если срок его жизни не истек.
+func (m *expiringMap[V]) get(key string, now time.Time) (V, bool) {
+	if el, ok := m.items[key]; ok {
+		item := el.Value.(*expiringItem[V])
+		if !now.After(item.expires) {
+			return item.value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// set сохраняет значение на ttl от now и вытесняет самые старые записи
+// сверх limit.
+func (m *expiringMap[V]) set(key string, value V, now time.Time) {
+	m.prune(now)
+	m.delete(key)
+	m.items[key] = m.order.PushBack(&expiringItem[V]{key: key, value: value, expires: now.Add(m.ttl)})
+	for m.order.Len() > m.limit {
+		m.delete(m.order.Front().Value.(*expiringItem[V]).key)
+	}
+}
+
+func (m *expiringMap[V]) delete(key string) {
+	if el, ok := m.items[key]; ok {
+		m.order.Remove(el)
+		delete(m.items, key)
+	}
+}
+
+func (m *expiringMap[V]) len() int {
+	return m.order.Len()
+}
+
+// prune удаляет истекшие записи не чаще раза в минуту.
+func (m *expiringMap[V]) prune(now time.Time) {
+	if now.Sub(m.pruned) < pruneInterval {
+		return
+	}
+	m.pruned = now
+	for el := m.order.Front(); el != nil; el = m.order.Front() {
+		item := el.Value.(*expiringItem[V])
+		if !now.After(item.expires) {
+			return
+		}
+		m.delete(item.key)
+	}
+}
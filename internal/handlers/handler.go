@@ -1,52 +1,300 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 
+	"github.com/gegxkss/wbL0/internal/broadcast"
 	"github.com/gegxkss/wbL0/internal/cache"
 	"github.com/gegxkss/wbL0/internal/models"
-	"gorm.io/gorm"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func SetupRoutes(cache *cache.Cache, db *gorm.DB) {
-	fs := http.FileServer(http.Dir("./front"))
-	http.Handle("/", fs)
-	http.HandleFunc("/order/", func(w http.ResponseWriter, r *http.Request) {
+var tracer = otel.Tracer("github.com/gegxkss/wbL0/internal/handlers")
+
+const requestIDHeader = "X-Request-ID"
+
+// apiPrefix — префикс версии REST API.
+const apiPrefix = "/api/v1"
+
+// apiHandler получает контекст с извлеченной трассировкой и логгер запроса.
+type apiHandler func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger)
+
+type router struct {
+	mux    *http.ServeMux
+	log    *slog.Logger
+	routes map[string]map[string]apiHandler
+}
+
+func newRouter(mux *http.ServeMux, log *slog.Logger) *router {
+	return &router{mux: mux, log: log, routes: make(map[string]map[string]apiHandler)}
+}
+
+// corsAllowHeaders — заголовки запроса, которые браузер может отправлять
+// с других источников.
+var corsAllowHeaders = strings.Join([]string{"Content-Type", idempotencyKeyHeader, "Last-Event-ID", requestIDHeader, "traceparent", "tracestate"}, ", ")
+
+// corsExposeHeaders — заголовки ответа, доступные скриптам с других источников.
+var corsExposeHeaders = strings.Join([]string{"Location", "Idempotent-Replayed", requestIDHeader, "Deprecation", "Link"}, ", ")
+
+// handle регистрирует обработчик метода на маршруте. OPTIONS отвечает на
+// предварительный CORS запрос списком методов и заголовков. Остальные методы,
+// для которых нет обработчика, получают 405 с заголовком Allow и телом problem+json.
+func (rt *router) handle(method, pattern string, h apiHandler) {
+	if methods, ok := rt.routes[pattern]; ok {
+		methods[method] = h
+		return
+	}
+	methods := map[string]apiHandler{method: h}
+	rt.routes[pattern] = methods
+
+	rt.mux.HandleFunc(pattern, instrument(pattern, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
 
-		pathParts := strings.Split(r.URL.Path, "/")
-		if len(pathParts) < 3 || pathParts[2] == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Order ID is required"})
+		h, ok := methods[r.Method]
+		if !ok && r.Method == http.MethodOptions {
+			allow := strings.Join(append(slices.Sorted(maps.Keys(methods)), http.MethodOptions), ", ")
+			w.Header().Del("Content-Type")
+			w.Header().Set("Allow", allow)
+			w.Header().Set("Access-Control-Allow-Methods", allow)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !ok {
+			allow := slices.Sorted(maps.Keys(methods))
+			w.Header().Set("Allow", strings.Join(allow, ", "))
+			writeProblem(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, r.Method+" is not allowed, use "+strings.Join(allow, " or "))
 			return
 		}
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		h(ctx, w, r, requestLogger(w, r, rt.log))
+	}))
+}
+
+// SetupRoutes регистрирует REST API /api/v1, статику фронтенда, /metrics
+// и устаревшие адреса /order/{id}, /order/{id}/raw, /orders/by-track/{track}
+// и /orders/by-transaction/{tx}. Без ingest прием заказов POST /api/v1/orders
+// не регистрируется, без broadcaster — поток GET /api/v1/orders/stream.
+func SetupRoutes(mux *http.ServeMux, cache *cache.Cache, repo repository.OrderRepository, ingest *Ingest, broadcaster *broadcast.Broadcaster, log *slog.Logger) {
+	rt := newRouter(mux, log.With("component", "http"))
+
+	fs := http.FileServer(http.Dir("./front"))
+	mux.Handle("/", fs)
+	mux.Handle("/metrics", promhttp.Handler())
+	setupDocs(mux)
+
+	rt.handle(http.MethodGet, apiPrefix+"/orders", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		listOrders(ctx, w, r.URL.Query(), repo, log)
+	})
+	if ingest != nil {
+		rt.handle(http.MethodPost, apiPrefix+"/orders", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+			ingest.submitOrder(ctx, w, r, cache, repo, log)
+		})
+	}
+	if broadcaster != nil {
+		rt.handle(http.MethodGet, apiPrefix+"/orders/stream", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+			streamOrders(ctx, w, r, broadcaster, log)
+		})
+	}
+	rt.handle(http.MethodPost, apiPrefix+"/orders:batchGet", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		batchGetOrders(ctx, w, r, cache, repo, log)
+	})
+	rt.handle(http.MethodGet, apiPrefix+"/orders/{order_uid}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		orderUID := r.PathValue("order_uid")
+		getOrder(ctx, w, orderUID, cache, repo, log.With("order_uid", orderUID))
+	})
+	rt.handle(http.MethodGet, apiPrefix+"/orders/{order_uid}/raw", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		orderUID := r.PathValue("order_uid")
+		getOrderMessage(ctx, w, orderUID, repo, log.With("order_uid", orderUID))
+	})
+	rt.handle(http.MethodGet, apiPrefix+"/orders/{order_uid}/status", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		orderUID := r.PathValue("order_uid")
+		getOrderStatus(ctx, w, orderUID, cache, repo, ingest, log.With("order_uid", orderUID))
+	})
+	// Заказ по трек-номеру и транзакции не лежит под /api/v1/orders/by-track/{track}:
+	// такой шаблон конфликтует в ServeMux с /api/v1/orders/{order_uid}/raw
+	// (путь /api/v1/orders/by-track/raw подходит обоим, и ни один не точнее).
+	// Прежние адреса /orders/by-track/{track} и /orders/by-transaction/{tx}
+	// оставлены устаревшими синонимами ниже.
+	rt.handle(http.MethodGet, apiPrefix+"/track-numbers/{track_number}/order", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		track := r.PathValue("track_number")
+		getOrderByTrackNumber(ctx, w, track, cache, repo, log.With("track_number", track))
+	})
+	rt.handle(http.MethodGet, apiPrefix+"/transactions/{transaction}/order", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		tx := r.PathValue("transaction")
+		getOrderByTransaction(ctx, w, tx, cache, repo, log.With("transaction", tx))
+	})
+	rt.handle(http.MethodGet, apiPrefix+"/customers/{customer_id}/orders", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		customerID := r.PathValue("customer_id")
+		listCustomerOrders(ctx, w, customerID, r.URL.Query(), repo, log.With("customer_id", customerID))
+	})
+	rt.handle(http.MethodGet, apiPrefix+"/search", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		searchOrders(ctx, w, r.URL.Query(), repo, log)
+	})
+	mux.HandleFunc(apiPrefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, http.StatusNotFound, CodeNotFound, "Unknown API endpoint "+r.URL.Path)
+	})
 
-		orderUID := pathParts[2]
-		getOrder(w, orderUID, cache, db)
+	// Устаревший адрес, оставлен для старых клиентов. Ответ совпадает
+	// с /api/v1/orders/{order_uid}, заголовки указывают на новый адрес.
+	rt.handle(http.MethodGet, "/order/{order_uid}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		orderUID := r.PathValue("order_uid")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+apiPrefix+"/orders/"+url.PathEscape(orderUID)+`>; rel="successor-version"`)
+		getOrder(ctx, w, orderUID, cache, repo, log.With("order_uid", orderUID, "deprecated_route", true))
+	})
+	rt.handle(http.MethodGet, "/order/{order_uid}/raw", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		orderUID := r.PathValue("order_uid")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+apiPrefix+"/orders/"+url.PathEscape(orderUID)+`/raw>; rel="successor-version"`)
+		getOrderMessage(ctx, w, orderUID, repo, log.With("order_uid", orderUID, "deprecated_route", true))
 	})
+	mux.HandleFunc("/order/{$}", func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "Order ID is required")
+	})
+	rt.handle(http.MethodGet, "/orders/by-track/{track}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		track := r.PathValue("track")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+apiPrefix+"/track-numbers/"+url.PathEscape(track)+`/order>; rel="successor-version"`)
+		getOrderByTrackNumber(ctx, w, track, cache, repo, log.With("track_number", track, "deprecated_route", true))
+	})
+	rt.handle(http.MethodGet, "/orders/by-transaction/{tx}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+		tx := r.PathValue("tx")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+apiPrefix+"/transactions/"+url.PathEscape(tx)+`/order>; rel="successor-version"`)
+		getOrderByTransaction(ctx, w, tx, cache, repo, log.With("transaction", tx, "deprecated_route", true))
+	})
+}
+
+// requestLogger берет ID запроса из заголовка X-Request-ID или генерирует новый
+// и возвращает его клиенту.
+func requestLogger(w http.ResponseWriter, r *http.Request, log *slog.Logger) *slog.Logger {
+	requestID := r.Header.Get(requestIDHeader)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	w.Header().Set(requestIDHeader, requestID)
+	return log.With("request_id", requestID)
+}
+
+func getOrder(ctx context.Context, w http.ResponseWriter, orderUID string, cache *cache.Cache, repo repository.OrderRepository, log *slog.Logger) {
+	serveOrder(ctx, w, orderLookup{
+		route:     apiPrefix + "/orders/{order_uid}",
+		attribute: attribute.String("order.uid", orderUID),
+		fromCache: func() (interface{}, bool) { return cache.Get(orderUID) },
+		fromRepo:  func(ctx context.Context) (*models.Order, error) { return repo.Get(ctx, orderUID) },
+	}, cache, log)
+}
+
+func getOrderByTrackNumber(ctx context.Context, w http.ResponseWriter, track string, cache *cache.Cache, repo repository.OrderRepository, log *slog.Logger) {
+	serveOrder(ctx, w, orderLookup{
+		route:     apiPrefix + "/track-numbers/{track_number}/order",
+		attribute: attribute.String("order.track_number", track),
+		fromCache: func() (interface{}, bool) { return cache.GetByTrackNumber(track) },
+		fromRepo:  func(ctx context.Context) (*models.Order, error) { return repo.GetByTrackNumber(ctx, track) },
+	}, cache, log)
+}
+
+func getOrderByTransaction(ctx context.Context, w http.ResponseWriter, tx string, cache *cache.Cache, repo repository.OrderRepository, log *slog.Logger) {
+	serveOrder(ctx, w, orderLookup{
+		route:     apiPrefix + "/transactions/{transaction}/order",
+		attribute: attribute.String("payment.transaction", tx),
+		fromCache: func() (interface{}, bool) { return cache.GetByTransaction(tx) },
+		fromRepo:  func(ctx context.Context) (*models.Order, error) { return repo.GetByTransaction(ctx, tx) },
+	}, cache, log)
+}
+
+// orderLookup описывает поиск одного заказа: сначала в кэше, затем в базе.
+type orderLookup struct {
+	route     string
+	attribute attribute.KeyValue
+	fromCache func() (interface{}, bool)
+	fromRepo  func(ctx context.Context) (*models.Order, error)
 }
 
-func getOrder(w http.ResponseWriter, orderUID string, cache *cache.Cache, db *gorm.DB) {
-	if cached, found := cache.Get(orderUID); found {
-		log.Printf("Found order in cache: %s", orderUID)
+func serveOrder(ctx context.Context, w http.ResponseWriter, lookup orderLookup, cache *cache.Cache, log *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "handlers.getOrder",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRoute(lookup.route),
+			lookup.attribute,
+		),
+	)
+	defer span.End()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+	if cached, found := lookup.fromCache(); found {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		log.Debug("order served from cache")
 		json.NewEncoder(w).Encode(cached)
 		return
 	}
+	span.SetAttributes(attribute.Bool("cache.hit", false))
 
-	log.Printf("Order not found in cache, searching in DB: %s", orderUID)
-
-	var order models.Order
-	if err := db.Preload("Delivery").Preload("Payment").Preload("Items").
-		Where("order_uid = ?", orderUID).First(&order).Error; err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Order not found"})
+	order, err := lookup.fromRepo(ctx)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Info("order not found")
+		writeProblem(w, http.StatusNotFound, CodeOrderNotFound, "Order not found")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Error("get order failed", "error", err)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
 		return
 	}
 
-	cache.Set(orderUID, &order)
+	cache.Set(order.OrderUID, order)
+	log.Debug("order loaded from database", "order_uid", order.OrderUID)
 	json.NewEncoder(w).Encode(order)
 }
+
+// getOrderMessage отдает исходное сообщение Kafka, из которого сохранен заказ.
+// Кэш не используется: запрос нужен редко, для разбора проблем.
+func getOrderMessage(ctx context.Context, w http.ResponseWriter, orderUID string, repo repository.OrderRepository, log *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "handlers.getOrderMessage",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRoute(apiPrefix+"/orders/{order_uid}/raw"),
+			attribute.String("order.uid", orderUID),
+		),
+	)
+	defer span.End()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+	msg, err := repo.GetMessage(ctx, orderUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Info("order message not found")
+		writeProblem(w, http.StatusNotFound, CodeOrderMessageNotFound, "Order message not found")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Error("get order message failed", "error", err)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
+		return
+	}
+
+	json.NewEncoder(w).Encode(msg)
+}
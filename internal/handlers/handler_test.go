@@ -1,39 +1,37 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
 )
 
-// Мок для базы данных
-
-type mockDB struct{}
+// failingRepo имитирует недоступную базу данных.
+type failingRepo struct {
+	repository.OrderRepository
+}
 
-func (m *mockDB) Preload(string) *mockDB               { return m }
-func (m *mockDB) Where(string, ...interface{}) *mockDB { return m }
-func (m *mockDB) First(dest interface{}) error {
-	return nil // всегда "находит" заказ
+func (failingRepo) Get(context.Context, string) (*models.Order, error) {
+	return nil, errors.New("connection refused")
 }
 
-func TestSetupRoutes_OrderNotFound(t *testing.T) {
+func newTestMux(c *cache.Cache, repo repository.OrderRepository) *http.ServeMux {
 	mux := http.NewServeMux()
-	fs := http.FileServer(http.Dir("./front"))
-	mux.Handle("/", fs)
-	mux.HandleFunc("/order/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		pathParts := strings.Split(r.URL.Path, "/")
-		if len(pathParts) < 3 || pathParts[2] == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Order ID is required"})
-			return
-		}
-	})
+	SetupRoutes(mux, c, repo, nil, nil, logger.Nop())
+	return mux
+}
+
+func TestSetupRoutes_OrderIDRequired(t *testing.T) {
+	mux := newTestMux(cache.NewCache(logger.Nop()), repository.NewMemory())
 
 	req := httptest.NewRequest("GET", "/order/", nil)
 	w := httptest.NewRecorder()
@@ -44,30 +42,23 @@ func TestSetupRoutes_OrderNotFound(t *testing.T) {
 	}
 }
 
+func TestSetupRoutes_OrderNotFound(t *testing.T) {
+	mux := newTestMux(cache.NewCache(logger.Nop()), repository.NewMemory())
+
+	req := httptest.NewRequest("GET", "/order/missing", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", w.Code)
+	}
+}
+
 func TestSetupRoutes_OrderFoundInCache(t *testing.T) {
-	c := cache.NewCache()
+	c := cache.NewCache(logger.Nop())
 	orderUID := "test-uid"
-	c.Set(orderUID, map[string]string{"order_uid": orderUID})
-	mux := http.NewServeMux()
-	fs := http.FileServer(http.Dir("./front"))
-	mux.Handle("/", fs)
-	mux.HandleFunc("/order/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		pathParts := strings.Split(r.URL.Path, "/")
-		if len(pathParts) < 3 || pathParts[2] == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Order ID is required"})
-			return
-		}
-		orderUID := pathParts[2]
-		if cached, found := c.Get(orderUID); found {
-			json.NewEncoder(w).Encode(cached)
-			return
-		}
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Order not found"})
-	})
+	c.Set(orderUID, &models.Order{OrderUID: orderUID})
+	mux := newTestMux(c, repository.NewMemory())
 
 	req := httptest.NewRequest("GET", "/order/"+orderUID, nil)
 	w := httptest.NewRecorder()
@@ -77,3 +68,221 @@ func TestSetupRoutes_OrderFoundInCache(t *testing.T) {
 		t.Errorf("Expected 200, got %d", w.Code)
 	}
 }
+
+func TestSetupRoutes_OrderLoadedFromRepositoryAndCached(t *testing.T) {
+	c := cache.NewCache(logger.Nop())
+	repo := repository.NewMemory()
+	orderUID := "test-uid"
+	if err := repo.Save(context.Background(), &models.Order{OrderUID: orderUID, TrackNumber: "TRACK"}); err != nil {
+		t.Fatal(err)
+	}
+	mux := newTestMux(c, repo)
+
+	req := httptest.NewRequest("GET", "/order/"+orderUID, nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var order models.Order
+	if err := json.NewDecoder(w.Body).Decode(&order); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if order.TrackNumber != "TRACK" {
+		t.Errorf("Unexpected order: %+v", order)
+	}
+	if _, ok := c.Get(orderUID); !ok {
+		t.Error("Order was not cached")
+	}
+}
+
+func TestSetupRoutes_OrderRawMessage(t *testing.T) {
+	repo := repository.NewMemory()
+	msg := &models.OrderMessage{
+		Topic:     "order",
+		Partition: 3,
+		Offset:    10,
+		Key:       "test-uid",
+		Payload:   json.RawMessage(`{"order_uid":"test-uid"}`),
+	}
+	if err := repo.SaveWithMessage(context.Background(), &models.Order{OrderUID: "test-uid"}, msg); err != nil {
+		t.Fatal(err)
+	}
+	mux := newTestMux(cache.NewCache(logger.Nop()), repo)
+
+	req := httptest.NewRequest("GET", "/api/v1/orders/test-uid/raw", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var got models.OrderMessage
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got.Partition != 3 || got.Offset != 10 || string(got.Payload) != `{"order_uid":"test-uid"}` {
+		t.Errorf("Unexpected raw message: %+v", got)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/orders/missing/raw", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for missing message, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/orders/test-uid/unknown", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown subresource, got %d", w.Code)
+	}
+}
+
+func TestSetupRoutes_RepositoryError(t *testing.T) {
+	mux := newTestMux(cache.NewCache(logger.Nop()), failingRepo{})
+
+	req := httptest.NewRequest("GET", "/order/test-uid", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", w.Code)
+	}
+}
+
+func TestRequestLogger_PropagatesRequestID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/order/test-uid", nil)
+	req.Header.Set(requestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+
+	requestLogger(w, req, logger.Nop())
+
+	if got := w.Header().Get(requestIDHeader); got != "req-1" {
+		t.Errorf("Expected request ID req-1, got %q", got)
+	}
+}
+
+func TestRequestLogger_GeneratesRequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	requestLogger(w, httptest.NewRequest("GET", "/order/test-uid", nil), logger.Nop())
+
+	if w.Header().Get(requestIDHeader) == "" {
+		t.Error("Expected generated request ID")
+	}
+}
+
+func TestAPI_ProblemResponses(t *testing.T) {
+	mux := newTestMux(cache.NewCache(logger.Nop()), repository.NewMemory())
+
+	cases := []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{"GET", "/api/v1/orders/missing", http.StatusNotFound, CodeOrderNotFound},
+		{"POST", "/api/v1/orders/missing", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"DELETE", "/api/v1/search", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"GET", "/api/v1/orders?limit=0", http.StatusBadRequest, CodeInvalidParameter},
+		{"GET", "/api/v1/orders?cursor=broken", http.StatusBadRequest, CodeInvalidCursor},
+		{"GET", "/api/v1/unknown", http.StatusNotFound, CodeNotFound},
+		{"GET", "/order/", http.StatusBadRequest, CodeInvalidParameter},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if w.Code != tc.status {
+			t.Errorf("%s %s: expected %d, got %d", tc.method, tc.path, tc.status, w.Code)
+			continue
+		}
+		if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+			t.Errorf("%s %s: expected %s, got %q", tc.method, tc.path, problemContentType, ct)
+		}
+		var p Problem
+		if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+			t.Errorf("%s %s: decode failed: %v", tc.method, tc.path, err)
+			continue
+		}
+		if p.Code != tc.code || p.Status != tc.status || p.Title != http.StatusText(tc.status) || p.Type != "about:blank" {
+			t.Errorf("%s %s: unexpected problem %+v", tc.method, tc.path, p)
+		}
+		if tc.status == http.StatusMethodNotAllowed && w.Header().Get("Allow") != "GET" {
+			t.Errorf("%s %s: expected Allow: GET, got %q", tc.method, tc.path, w.Header().Get("Allow"))
+		}
+	}
+}
+
+func TestSetupRoutes_DeprecatedOrderAlias(t *testing.T) {
+	c := cache.NewCache(logger.Nop())
+	order := &models.Order{OrderUID: "test-uid", TrackNumber: "TRACK 1", Payment: models.Payment{Transaction: "tx-1"}}
+	c.Set(order.OrderUID, order)
+	repo := repository.NewMemory()
+	if err := repo.SaveWithMessage(context.Background(), order, &models.OrderMessage{Payload: json.RawMessage(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	mux := newTestMux(c, repo)
+
+	for _, path := range []string{"/order/test-uid", "/api/v1/orders/test-uid"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, w.Code)
+		}
+		deprecated := w.Header().Get("Deprecation") == "true"
+		if deprecated != (path == "/order/test-uid") {
+			t.Errorf("%s: unexpected Deprecation header %q", path, w.Header().Get("Deprecation"))
+		}
+	}
+
+	links := map[string]string{
+		"/order/test-uid":             `</api/v1/orders/test-uid>; rel="successor-version"`,
+		"/order/test-uid/raw":         `</api/v1/orders/test-uid/raw>; rel="successor-version"`,
+		"/orders/by-track/TRACK 1":    `</api/v1/track-numbers/TRACK%201/order>; rel="successor-version"`,
+		"/orders/by-transaction/tx-1": `</api/v1/transactions/tx-1/order>; rel="successor-version"`,
+	}
+	for path, want := range links {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", strings.ReplaceAll(path, " ", "%20"), nil))
+		if w.Code != http.StatusOK || w.Header().Get("Deprecation") != "true" {
+			t.Errorf("%s: expected deprecated 200, got %d", path, w.Code)
+		}
+		if link := w.Header().Get("Link"); link != want {
+			t.Errorf("%s: unexpected Link header %q", path, link)
+		}
+	}
+}
+
+func TestSetupRoutes_CORSPreflight(t *testing.T) {
+	mux := http.NewServeMux()
+	SetupRoutes(mux, cache.NewCache(logger.Nop()), repository.NewMemory(), NewIngest(nil, "orders"), nil, logger.Nop())
+
+	req := httptest.NewRequest("OPTIONS", "/api/v1/orders", nil)
+	req.Header.Set("Origin", "https://partner.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type, idempotency-key")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, OPTIONS" {
+		t.Errorf("Expected GET, POST, OPTIONS, got %q", got)
+	}
+	allowHeaders := strings.ToLower(w.Header().Get("Access-Control-Allow-Headers"))
+	for _, h := range []string{"content-type", "idempotency-key"} {
+		if !strings.Contains(allowHeaders, h) {
+			t.Errorf("Expected %s in Access-Control-Allow-Headers, got %q", h, allowHeaders)
+		}
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("Expected Access-Control-Allow-Origin: *, got %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Errorf("Expected empty preflight response, got %q (%s)", w.Body.String(), w.Header().Get("Content-Type"))
+	}
+}
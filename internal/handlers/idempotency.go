@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotencyTTL — сколько хранится результат запроса с ключом.
+	idempotencyTTL = 24 * time.Hour
+	// maxIdempotencyKey ограничивает длину ключа.
+	maxIdempotencyKey = 255
+	// maxIdempotencyKeys ограничивает число хранимых ключей; при переполнении
+	// вытесняются самые старые.
+	maxIdempotencyKeys = 100_000
+)
+
+var (
+	errKeyInProgress = errors.New("request with this Idempotency-Key is in progress")
+	errKeyReused     = errors.New("Idempotency-Key was already used with a different request body")
+)
+
+type idempotencyEntry struct {
+	fingerprint [sha256.Size]byte
+	// orderUID заполняется после успешной публикации; пока он пуст,
+	// запрос с этим ключом выполняется.
+	orderUID string
+}
+
+// idempotencyStore запоминает результаты запросов по Idempotency-Key,
+// чтобы повтор запроса не публиковал заказ второй раз. Ключи хранятся
+// в памяти процесса, как и кэш заказов: гарантия действует в пределах
+// одного экземпляра сервиса и теряется при перезапуске.
+type idempotencyStore struct {
+	mu      sync.Mutex
+	entries *expiringMap[*idempotencyEntry]
+	now     func() time.Time
+}
+
+func newIdempotencyStore(ttl time.Duration, limit int) *idempotencyStore {
+	return &idempotencyStore{
+		entries: newExpiringMap[*idempotencyEntry](ttl, limit),
+		now:     time.Now,
+	}
+}
+
+// begin резервирует ключ за запросом с телом body. Если запрос с тем же
+// ключом и телом уже выполнен, возвращает его order_uid.
+func (s *idempotencyStore) begin(key string, body []byte) (string, error) {
+	fingerprint := sha256.Sum256(body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if e, ok := s.entries.get(key, now); ok {
+		switch {
+		case e.fingerprint != fingerprint:
+			return "", errKeyReused
+		case e.orderUID == "":
+			return "", errKeyInProgress
+		default:
+			return e.orderUID, nil
+		}
+	}
+	s.entries.set(key, &idempotencyEntry{fingerprint: fingerprint}, now)
+	return "", nil
+}
+
+// complete сохраняет результат запроса с ключом.
+func (s *idempotencyStore) complete(key, orderUID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries.get(key, s.now()); ok {
+		e.orderUID = orderUID
+	}
+}
+
+// release освобождает ключ после неудачного запроса, чтобы клиент мог повторить его.
+func (s *idempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries.get(key, s.now()); ok && e.orderUID == "" {
+		s.entries.delete(key)
+	}
+}
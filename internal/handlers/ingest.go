@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxOrderBody — предельный размер заказа в POST /api/v1/orders.
+const maxOrderBody = 1 << 20
+
+// maxAcceptedOrders ограничивает число принятых, но еще не сохраненных
+// заказов, статус которых помнит сервис.
+const maxAcceptedOrders = 100_000
+
+const (
+	orderStatusAccepted = "accepted"
+	orderStatusStored   = "stored"
+)
+
+// Publisher отправляет сообщение в Kafka; реализуется kafka.Producer.
+type Publisher interface {
+	ProduceContext(ctx context.Context, message, topic, key string) error
+}
+
+// Ingest принимает заказы по HTTP и публикует их в топик заказов.
+// Дальше заказ проходит тот же путь, что и заказы из Kafka: консьюмер
+// проверяет его, сохраняет в базу и кэш.
+type Ingest struct {
+	publisher Publisher
+	topic     string
+	keys      *idempotencyStore
+
+	mu       sync.Mutex
+	accepted *expiringMap[struct{}]
+}
+
+func NewIngest(publisher Publisher, topic string) *Ingest {
+	return &Ingest{
+		publisher: publisher,
+		topic:     topic,
+		keys:      newIdempotencyStore(idempotencyTTL, maxIdempotencyKeys),
+		accepted:  newExpiringMap[struct{}](idempotencyTTL, maxAcceptedOrders),
+	}
+}
+
+// markAccepted запоминает опубликованный заказ, чтобы статус был виден
+// до того, как консьюмер сохранит его в базу. Как и ключи идемпотентности,
+// список живет в памяти процесса и теряется при перезапуске.
+func (in *Ingest) markAccepted(orderUID string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.accepted.set(orderUID, struct{}{}, time.Now())
+}
+
+func (in *Ingest) isAccepted(orderUID string) bool {
+	if in == nil {
+		return false
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	_, ok := in.accepted.get(orderUID, time.Now())
+	return ok
+}
+
+type submitResponse struct {
+	OrderUID  string `json:"order_uid"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+}
+
+type orderStatus struct {
+	OrderUID string `json:"order_uid"`
+	Status   string `json:"status"`
+}
+
+func statusURL(orderUID string) string {
+	return apiPrefix + "/orders/" + url.PathEscape(orderUID) + "/status"
+}
+
+func writeAccepted(w http.ResponseWriter, orderUID string) {
+	w.Header().Set("Location", statusURL(orderUID))
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(submitResponse{
+		OrderUID:  orderUID,
+		Status:    orderStatusAccepted,
+		StatusURL: statusURL(orderUID),
+	})
+}
+
+// submitOrder проверяет заказ и публикует его в Kafka с ключом order_uid.
+// Повтор запроса с тем же Idempotency-Key и телом возвращает исходный ответ
+// без повторной публикации.
+func (in *Ingest) submitOrder(ctx context.Context, w http.ResponseWriter, r *http.Request, cache *cache.Cache, repo repository.OrderRepository, log *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "handlers.submitOrder",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPRoute(apiPrefix+"/orders")),
+	)
+	defer span.End()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+	key := r.Header.Get(idempotencyKeyHeader)
+	if len(key) > maxIdempotencyKey {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "Idempotency-Key is too long")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxOrderBody))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeProblem(w, http.StatusRequestEntityTooLarge, CodeInvalidRequestBody, "Order is too large")
+		return
+	}
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidRequestBody, "Failed to read request body")
+		return
+	}
+
+	var order models.Order
+	if err := json.Unmarshal(body, &order); err != nil {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidRequestBody, "Invalid order JSON: "+err.Error())
+		return
+	}
+	if err := order.Validate(); err != nil {
+		writeProblem(w, http.StatusUnprocessableEntity, CodeInvalidOrder, err.Error())
+		return
+	}
+	span.SetAttributes(attribute.String("order.uid", order.OrderUID))
+	log = log.With("order_uid", order.OrderUID)
+
+	// В Kafka уходит заказ в текущем формате, даже если пришел старый
+	message, err := json.Marshal(&order)
+	if err != nil {
+		span.RecordError(err)
+		log.Error("marshal order failed", "error", err)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
+		return
+	}
+
+	if key != "" {
+		orderUID, err := in.keys.begin(key, message)
+		switch {
+		case errors.Is(err, errKeyInProgress):
+			writeProblem(w, http.StatusConflict, CodeIdempotencyKeyInProgress, err.Error())
+			return
+		case errors.Is(err, errKeyReused):
+			writeProblem(w, http.StatusUnprocessableEntity, CodeIdempotencyKeyReused, err.Error())
+			return
+		case orderUID != "":
+			log.Info("order submission replayed", "idempotency_key", key)
+			w.Header().Set("Idempotent-Replayed", "true")
+			writeAccepted(w, orderUID)
+			return
+		}
+	}
+	published := false
+	defer func() {
+		if key != "" && !published {
+			in.keys.release(key)
+		}
+	}()
+
+	exists := in.isAccepted(order.OrderUID)
+	if !exists {
+		if _, found := cache.Get(order.OrderUID); found {
+			exists = true
+		} else if _, err := repo.Get(ctx, order.OrderUID); err == nil {
+			exists = true
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			log.Error("check order failed", "error", err)
+			writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
+			return
+		}
+	}
+	if exists {
+		writeProblem(w, http.StatusConflict, CodeOrderExists, "Order "+order.OrderUID+" was already submitted")
+		return
+	}
+
+	if err := in.publisher.ProduceContext(ctx, string(message), in.topic, order.OrderUID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Error("publish order failed", "error", err)
+		writeProblem(w, http.StatusServiceUnavailable, CodePublishFailed, "Failed to publish order, retry later")
+		return
+	}
+	published = true
+	if key != "" {
+		in.keys.complete(key, order.OrderUID)
+	}
+	in.markAccepted(order.OrderUID)
+
+	log.Info("order accepted", "topic", in.topic)
+	writeAccepted(w, order.OrderUID)
+}
+
+// getOrderStatus сообщает, сохранен ли заказ или только принят к обработке.
+func getOrderStatus(ctx context.Context, w http.ResponseWriter, orderUID string, cache *cache.Cache, repo repository.OrderRepository, ingest *Ingest, log *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "handlers.getOrderStatus",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRoute(apiPrefix+"/orders/{order_uid}/status"),
+			attribute.String("order.uid", orderUID),
+		),
+	)
+	defer span.End()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+	status := orderStatus{OrderUID: orderUID, Status: orderStatusStored}
+	if _, found := cache.Get(orderUID); found {
+		json.NewEncoder(w).Encode(status)
+		return
+	}
+	_, err := repo.Get(ctx, orderUID)
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrNotFound) && ingest.isAccepted(orderUID):
+		status.Status = orderStatusAccepted
+	case errors.Is(err, repository.ErrNotFound):
+		writeProblem(w, http.StatusNotFound, CodeOrderNotFound, "Order not found")
+		return
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Error("get order status failed", "error", err)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
+		return
+	}
+	json.NewEncoder(w).Encode(status)
+}
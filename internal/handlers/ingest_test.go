@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+)
+
+type publishedMessage struct {
+	message, topic, key string
+}
+
+// fakePublisher запоминает отправленные сообщения вместо Kafka.
+type fakePublisher struct {
+	mu       sync.Mutex
+	messages []publishedMessage
+	err      error
+}
+
+func (p *fakePublisher) ProduceContext(_ context.Context, message, topic, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, publishedMessage{message, topic, key})
+	return nil
+}
+
+func (p *fakePublisher) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.messages)
+}
+
+func newIngestMux(c *cache.Cache, repo repository.OrderRepository, pub Publisher) *http.ServeMux {
+	mux := http.NewServeMux()
+	SetupRoutes(mux, c, repo, NewIngest(pub, "order"), nil, logger.Nop())
+	return mux
+}
+
+func validOrderJSON(t *testing.T, orderUID string) string {
+	t.Helper()
+	order := models.Order{
+		OrderUID:    orderUID,
+		DateCreated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Payment: models.Payment{
+			Currency:     "USD",
+			Amount:       models.NewMoney(1500, "USD"),
+			GoodsTotal:   models.NewMoney(1000, "USD"),
+			DeliveryCost: models.NewMoney(500, "USD"),
+		},
+		Items: []models.Items{{Name: "item", Price: models.NewMoney(1000, "USD"), TotalPrice: models.NewMoney(1000, "USD")}},
+	}
+	data, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func submit(mux *http.ServeMux, body, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/v1/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestSubmitOrder(t *testing.T) {
+	pub := &fakePublisher{}
+	mux := newIngestMux(cache.NewCache(logger.Nop()), repository.NewMemory(), pub)
+
+	w := submit(mux, validOrderJSON(t, "new-order"), "")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", w.Code, w.Body)
+	}
+	var resp submitResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if resp.StatusURL != "/api/v1/orders/new-order/status" || w.Header().Get("Location") != resp.StatusURL {
+		t.Errorf("Unexpected status URL %q, Location %q", resp.StatusURL, w.Header().Get("Location"))
+	}
+	if pub.count() != 1 {
+		t.Fatalf("Expected 1 published message, got %d", pub.count())
+	}
+	msg := pub.messages[0]
+	if msg.topic != "order" || msg.key != "new-order" {
+		t.Errorf("Unexpected message topic %q key %q", msg.topic, msg.key)
+	}
+	var published models.Order
+	if err := json.Unmarshal([]byte(msg.message), &published); err != nil || published.Validate() != nil {
+		t.Errorf("Published message is not a valid order: %s (%v)", msg.message, err)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", resp.StatusURL, nil))
+	var status orderStatus
+	json.NewDecoder(w.Body).Decode(&status)
+	if w.Code != http.StatusOK || status.Status != orderStatusAccepted {
+		t.Errorf("Expected accepted status, got %d %+v", w.Code, status)
+	}
+
+	// Повтор без ключа идемпотентности отклоняется по order_uid
+	if w := submit(mux, validOrderJSON(t, "new-order"), ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for resubmitted order, got %d", w.Code)
+	}
+	if pub.count() != 1 {
+		t.Errorf("Resubmitted order was published again")
+	}
+}
+
+func TestSubmitOrder_Idempotency(t *testing.T) {
+	pub := &fakePublisher{}
+	mux := newIngestMux(cache.NewCache(logger.Nop()), repository.NewMemory(), pub)
+	body := validOrderJSON(t, "idem-order")
+
+	first := submit(mux, body, "key-1")
+	second := submit(mux, body, "key-1")
+	if first.Code != http.StatusAccepted || second.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202 twice, got %d and %d", first.Code, second.Code)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" || first.Body.String() != second.Body.String() {
+		t.Errorf("Expected replayed response, got %s", second.Body)
+	}
+	if pub.count() != 1 {
+		t.Errorf("Expected 1 published message, got %d", pub.count())
+	}
+
+	w := submit(mux, validOrderJSON(t, "other-order"), "key-1")
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), CodeIdempotencyKeyReused) {
+		t.Errorf("Expected 422 for reused key, got %d: %s", w.Code, w.Body)
+	}
+
+	// После ошибки публикации ключ освобождается и запрос можно повторить
+	pub.err = errors.New("kafka unavailable")
+	body = validOrderJSON(t, "retry-order")
+	if w := submit(mux, body, "key-2"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d", w.Code)
+	}
+	pub.err = nil
+	if w := submit(mux, body, "key-2"); w.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 on retry, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestSubmitOrder_Invalid(t *testing.T) {
+	repo := repository.NewMemory()
+	if err := repo.Save(context.Background(), &models.Order{OrderUID: "stored"}); err != nil {
+		t.Fatal(err)
+	}
+	pub := &fakePublisher{}
+	mux := newIngestMux(cache.NewCache(logger.Nop()), repo, pub)
+
+	cases := map[string]struct {
+		body string
+		code int
+	}{
+		"not json":       {`{"order_uid":`, http.StatusBadRequest},
+		"no order_uid":   {validOrderJSON(t, ""), http.StatusUnprocessableEntity},
+		"wrong total":    {strings.Replace(validOrderJSON(t, "bad"), `"amount":1500`, `"amount":1600`, 1), http.StatusUnprocessableEntity},
+		"already stored": {validOrderJSON(t, "stored"), http.StatusConflict},
+		"too large":      {`{"order_uid": "` + strings.Repeat("x", maxOrderBody) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for name, tc := range cases {
+		if w := submit(mux, tc.body, ""); w.Code != tc.code {
+			t.Errorf("%s: expected %d, got %d: %.200s", name, tc.code, w.Code, w.Body)
+		}
+	}
+	if pub.count() != 0 {
+		t.Errorf("Invalid orders were published: %d", pub.count())
+	}
+
+	// Без Ingest прием заказов отключен
+	w := httptest.NewRecorder()
+	newTestMux(cache.NewCache(logger.Nop()), repo).ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/orders", strings.NewReader("{}")))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 without ingest, got %d", w.Code)
+	}
+}
+
+func TestIdempotencyStore_Expires(t *testing.T) {
+	s := newIdempotencyStore(time.Hour, 10)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	if _, err := s.begin("key", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.begin("key", []byte("a")); !errors.Is(err, errKeyInProgress) {
+		t.Errorf("Expected errKeyInProgress, got %v", err)
+	}
+	s.complete("key", "uid")
+	if uid, err := s.begin("key", []byte("a")); err != nil || uid != "uid" {
+		t.Errorf("Expected stored uid, got %q (%v)", uid, err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if uid, err := s.begin("key", []byte("b")); err != nil || uid != "" {
+		t.Errorf("Expected expired key to be reusable, got %q (%v)", uid, err)
+	}
+}
+
+func TestExpiringMap(t *testing.T) {
+	m := newExpiringMap[int](time.Hour, 2)
+	now := time.Now()
+
+	m.set("a", 1, now)
+	m.set("b", 2, now.Add(time.Minute))
+	m.set("c", 3, now.Add(2*time.Minute))
+	if _, ok := m.get("a", now); ok || m.len() != 2 {
+		t.Errorf("Expected oldest key to be evicted over limit, got len %d", m.len())
+	}
+	if v, ok := m.get("c", now); !ok || v != 3 {
+		t.Errorf("Expected c=3, got %d (%v)", v, ok)
+	}
+
+	// Повторная запись продлевает срок и переносит ключ в конец очереди
+	m.set("b", 4, now.Add(30*time.Minute))
+	later := now.Add(time.Hour + 10*time.Minute)
+	if _, ok := m.get("c", later); ok {
+		t.Error("Expected c to expire")
+	}
+	if v, ok := m.get("b", later); !ok || v != 4 {
+		t.Errorf("Expected renewed b=4, got %d (%v)", v, ok)
+	}
+
+	m = newExpiringMap[int](time.Hour, 10)
+	m.set("a", 1, now)
+	m.set("b", 2, now.Add(time.Minute))
+	m.set("c", 3, now.Add(2*time.Hour))
+	if m.len() != 1 {
+		t.Errorf("Expected expired keys to be pruned, got len %d", m.len())
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentCountsByRouteAndStatus(t *testing.T) {
+	h := instrument("/test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	counter := httpRequests.WithLabelValues("/test/{id}", "GET", "404")
+	before := testutil.ToFloat64(counter)
+
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "/test/abc", nil))
+
+	if got := testutil.ToFloat64(counter); got != before+1 {
+		t.Errorf("Expected %v requests, got %v", before+1, got)
+	}
+}
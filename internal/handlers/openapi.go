@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// openAPIVersion — версия документа API (info.version).
+const openAPIVersion = "1.0.0"
+
+// swaggerInitializer настраивает Swagger UI на спецификацию сервиса
+// вместо примера petstore из поставки swagger-ui.
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+var moneyType = reflect.TypeOf(models.Money{})
+
+// openAPISpec строится один раз: схемы генерируются из Go типов ответов
+// по тегам json, пути описаны вручную в openAPIPaths.
+var openAPISpec = sync.OnceValues(func() ([]byte, error) {
+	doc, err := buildOpenAPI()
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+})
+
+func setupDocs(mux *http.ServeMux) {
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		spec, err := openAPISpec()
+		if err != nil {
+			writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write(spec)
+	})
+	mux.Handle("GET /swagger/", http.StripPrefix("/swagger/", http.FileServerFS(swaggerFiles.FS)))
+	mux.HandleFunc("GET /swagger/swagger-initializer.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		w.Write([]byte(swaggerInitializer))
+	})
+}
+
+func buildOpenAPI() (*openapi3.T, error) {
+	schemas := openapi3.Schemas{}
+	gen := openapi3gen.NewGenerator(
+		openapi3gen.CreateComponentSchemas(openapi3gen.ExportComponentSchemasOptions{
+			ExportComponentSchemas: true,
+			ExportTopLevelSchema:   true,
+		}),
+		openapi3gen.CreateTypeNameGenerator(schemaName),
+		openapi3gen.SchemaCustomizer(customizeSchema),
+	)
+	for _, v := range []any{
+		models.Order{}, models.OrderMessage{}, Problem{},
+		orderList{}, customerOrders{}, searchResponse{},
+		batchGetRequest{}, batchGetResponse{},
+		submitResponse{}, orderStatus{},
+	} {
+		if _, err := gen.NewSchemaRefForValue(v, schemas); err != nil {
+			return nil, fmt.Errorf("generate schema for %T: %w", v, err)
+		}
+	}
+
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:       "wbL0 orders API",
+			Description: "Заказы, полученные из Kafka. Ошибки отдаются в формате RFC 7807 (application/problem+json).",
+			Version:     openAPIVersion,
+		},
+		Paths:      openAPIPaths(),
+		Components: &openapi3.Components{Schemas: schemas},
+	}
+
+	// Повторная загрузка разрешает ссылки $ref и проверяет документ целиком.
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	loader := openapi3.NewLoader()
+	if doc, err = loader.LoadFromData(data); err != nil {
+		return nil, fmt.Errorf("load openapi: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("validate openapi: %w", err)
+	}
+	return doc, nil
+}
+
+// schemaName переводит имя Go типа в имя схемы: orderList -> OrderList.
+func schemaName(t reflect.Type) string {
+	name := []rune(t.Name())
+	if len(name) == 0 {
+		return ""
+	}
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
+
+// customizeSchema приводит сгенерированные схемы к фактическому JSON:
+// Money сериализуется объектом {amount, currency, decimal}, поля без omitempty
+// обязательны, лишние поля запрещены, nil-срезы выводятся как null.
+func customizeSchema(_ string, t reflect.Type, _ reflect.StructTag, schema *openapi3.Schema) error {
+	if t == moneyType {
+		amount := openapi3.NewInt64Schema()
+		amount.Description = "Сумма в минимальных единицах валюты"
+		currency := openapi3.NewStringSchema()
+		currency.Description = "Код валюты ISO 4217"
+		decimal := openapi3.NewStringSchema()
+		decimal.Description = "Сумма в основных единицах с числом знаков валюты; в запросах игнорируется"
+		*schema = *openapi3.NewObjectSchema().
+			WithProperty("amount", amount).
+			WithProperty("currency", currency).
+			WithProperty("decimal", decimal)
+		schema.Required = []string{"amount", "currency"}
+		schema.AdditionalProperties = openapi3.AdditionalProperties{Has: new(bool)}
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			schema.Nullable = true
+		}
+	case reflect.Struct:
+		if schema.Properties == nil {
+			return nil
+		}
+		schema.Required = requiredFields(t)
+		schema.AdditionalProperties = openapi3.AdditionalProperties{Has: new(bool)}
+	}
+	return nil
+}
+
+// requiredFields возвращает JSON имена полей без omitempty, включая поля
+// встроенных структур.
+func requiredFields(t reflect.Type) []string {
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("json")
+		if f.Anonymous && !ok {
+			required = append(required, requiredFields(f.Type)...)
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if !ok || name == "-" || strings.Contains(opts, "omitempty") {
+			continue
+		}
+		required = append(required, name)
+	}
+	return required
+}
+
+func schemaRef(name string) *openapi3.SchemaRef {
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, nil)
+}
+
+// operation описывает метод API: успешный ответ и возможные ошибки.
+type operation struct {
+	id, summary string
+	params      openapi3.Parameters
+	body        string
+	status      int
+	result      string
+	errors      []int
+	deprecated  bool
+	// stream — успешный ответ отдается как text/event-stream, а не JSON.
+	stream bool
+}
+
+func (op operation) build() *openapi3.Operation {
+	o := openapi3.NewOperation()
+	o.OperationID = op.id
+	o.Summary = op.summary
+	o.Parameters = op.params
+	o.Deprecated = op.deprecated
+	if op.body != "" {
+		o.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+			WithRequired(true).
+			WithJSONSchemaRef(schemaRef(op.body))}
+	}
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if op.stream {
+		o.AddResponse(status, openapi3.NewResponse().
+			WithDescription("Server-Sent Events: событие "+streamEventOrder+" с заказом в data и номером в id, "+
+				"событие "+streamEventReset+", если пропущенные события восстановить нельзя").
+			WithContent(openapi3.Content{"text/event-stream": openapi3.NewMediaType().WithSchema(openapi3.NewStringSchema())}))
+	} else {
+		o.AddResponse(status, openapi3.NewResponse().
+			WithDescription(http.StatusText(status)).
+			WithJSONSchemaRef(schemaRef(op.result)))
+	}
+
+	// 405 и 500 возможны на любом маршруте API
+	for _, status := range append(op.errors, http.StatusMethodNotAllowed, http.StatusInternalServerError) {
+		o.AddResponse(status, openapi3.NewResponse().
+			WithDescription(http.StatusText(status)).
+			WithContent(openapi3.Content{problemContentType: openapi3.NewMediaType().WithSchemaRef(schemaRef("Problem"))}))
+	}
+	return o
+}
+
+// legacy описывает устаревший синоним операции со своими параметрами пути.
+func legacy(op operation, id, successor string, param *openapi3.ParameterRef) operation {
+	op.id = id
+	op.summary = "Устаревший синоним GET " + successor
+	op.params = openapi3.Parameters{param}
+	op.deprecated = true
+	return op
+}
+
+func pathParam(name, description string) *openapi3.ParameterRef {
+	return &openapi3.ParameterRef{Value: openapi3.NewPathParameter(name).
+		WithDescription(description).
+		WithSchema(openapi3.NewStringSchema())}
+}
+
+func queryParam(name, description string, schema *openapi3.Schema) *openapi3.ParameterRef {
+	return &openapi3.ParameterRef{Value: openapi3.NewQueryParameter(name).
+		WithDescription(description).
+		WithSchema(schema)}
+}
+
+func limitParam() *openapi3.ParameterRef {
+	return queryParam("limit", "Размер страницы", openapi3.NewIntegerSchema().
+		WithMin(1).
+		WithMax(repository.MaxPageSize))
+}
+
+func openAPIPaths() *openapi3.Paths {
+	orderUID := pathParam("order_uid", "Идентификатор заказа")
+	getOrder := operation{
+		id:      "getOrder",
+		summary: "Заказ по идентификатору",
+		params:  openapi3.Parameters{orderUID},
+		result:  "Order",
+		errors:  []int{http.StatusNotFound},
+	}
+	getOrderMessage := operation{
+		id:      "getOrderMessage",
+		summary: "Исходное сообщение Kafka",
+		params:  openapi3.Parameters{orderUID},
+		result:  "OrderMessage",
+		errors:  []int{http.StatusNotFound},
+	}
+	byTrack := operation{
+		id:      "getOrderByTrackNumber",
+		summary: "Самый новый заказ с трек-номером",
+		params:  openapi3.Parameters{pathParam("track_number", "Трек-номер")},
+		result:  "Order",
+		errors:  []int{http.StatusNotFound},
+	}
+	byTransaction := operation{
+		id:      "getOrderByTransaction",
+		summary: "Самый новый заказ с транзакцией оплаты",
+		params:  openapi3.Parameters{pathParam("transaction", "Транзакция оплаты")},
+		result:  "Order",
+		errors:  []int{http.StatusNotFound},
+	}
+
+	dateTime := openapi3.NewDateTimeSchema()
+	paths := map[string]map[string]operation{
+		apiPrefix + "/orders": {http.MethodGet: {
+			id:      "listOrders",
+			summary: "Список заказов от новых к старым",
+			params: openapi3.Parameters{
+				limitParam(),
+				queryParam("cursor", "Курсор следующей страницы (next_cursor)", openapi3.NewStringSchema()),
+				queryParam("customer_id", "Клиент", openapi3.NewStringSchema()),
+				queryParam("track_number", "Трек-номер", openapi3.NewStringSchema()),
+				queryParam("delivery_service", "Служба доставки", openapi3.NewStringSchema()),
+				queryParam("locale", "Локаль", openapi3.NewStringSchema()),
+				queryParam("brand", "Бренд товара", openapi3.NewStringSchema()),
+				queryParam("created_from", "Создан не раньше (RFC 3339)", dateTime),
+				queryParam("created_to", "Создан раньше (RFC 3339)", dateTime),
+				queryParam("count", "Посчитать общее число заказов", openapi3.NewBoolSchema()),
+			},
+			result: "OrderList",
+			errors: []int{http.StatusBadRequest},
+		}, http.MethodPost: {
+			id:      "submitOrder",
+			summary: "Принять заказ и опубликовать его в Kafka",
+			params: openapi3.Parameters{&openapi3.ParameterRef{Value: openapi3.NewHeaderParameter(idempotencyKeyHeader).
+				WithDescription("Ключ идемпотентности: повтор с тем же ключом и телом не публикует заказ повторно").
+				WithSchema(openapi3.NewStringSchema().WithMaxLength(maxIdempotencyKey))}},
+			body:   "Order",
+			status: http.StatusAccepted,
+			result: "SubmitResponse",
+			errors: []int{
+				http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge,
+				http.StatusUnprocessableEntity, http.StatusServiceUnavailable,
+			},
+		}},
+		apiPrefix + "/orders/stream": {http.MethodGet: {
+			id:      "streamOrders",
+			summary: "Поток сохраненных заказов (Server-Sent Events)",
+			params: openapi3.Parameters{
+				queryParam("customer_id", "Клиент", openapi3.NewStringSchema()),
+				queryParam("delivery_service", "Служба доставки", openapi3.NewStringSchema()),
+				queryParam("last_event_id", "Номер последнего полученного события для первого подключения", openapi3.NewInt64Schema().WithMin(0)),
+				&openapi3.ParameterRef{Value: openapi3.NewHeaderParameter("Last-Event-ID").
+					WithDescription("Номер последнего полученного события; браузер отправляет его при переподключении").
+					WithSchema(openapi3.NewInt64Schema().WithMin(0))},
+			},
+			stream: true,
+			errors: []int{http.StatusBadRequest},
+		}},
+		apiPrefix + "/orders:batchGet": {http.MethodPost: {
+			id:      "batchGetOrders",
+			summary: "Пакетное получение заказов",
+			body:    "BatchGetRequest",
+			result:  "BatchGetResponse",
+			errors:  []int{http.StatusBadRequest},
+		}},
+		apiPrefix + "/orders/{order_uid}":     {http.MethodGet: getOrder},
+		apiPrefix + "/orders/{order_uid}/raw": {http.MethodGet: getOrderMessage},
+		apiPrefix + "/orders/{order_uid}/status": {http.MethodGet: {
+			id:      "getOrderStatus",
+			summary: "Статус принятого заказа: accepted или stored",
+			params:  openapi3.Parameters{orderUID},
+			result:  "OrderStatus",
+			errors:  []int{http.StatusNotFound},
+		}},
+		apiPrefix + "/track-numbers/{track_number}/order": {http.MethodGet: byTrack},
+		apiPrefix + "/transactions/{transaction}/order":   {http.MethodGet: byTransaction},
+		apiPrefix + "/customers/{customer_id}/orders": {http.MethodGet: {
+			id:      "listCustomerOrders",
+			summary: "Заказы клиента и сводка",
+			params: openapi3.Parameters{
+				pathParam("customer_id", "Клиент"),
+				limitParam(),
+				queryParam("cursor", "Курсор следующей страницы (next_cursor)", openapi3.NewStringSchema()),
+			},
+			result: "CustomerOrders",
+			errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		}},
+		apiPrefix + "/search": {http.MethodGet: {
+			id:      "searchOrders",
+			summary: "Поиск по товарам и получателю",
+			params: openapi3.Parameters{
+				&openapi3.ParameterRef{Value: openapi3.NewQueryParameter("q").
+					WithDescription("Строка поиска").
+					WithRequired(true).
+					WithSchema(openapi3.NewStringSchema().WithMinLength(minSearchQuery).WithMaxLength(maxSearchQuery))},
+				limitParam(),
+			},
+			result: "SearchResponse",
+			errors: []int{http.StatusBadRequest},
+		}},
+		"/order/{order_uid}":          {http.MethodGet: legacy(getOrder, "getOrderLegacy", apiPrefix+"/orders/{order_uid}", orderUID)},
+		"/order/{order_uid}/raw":      {http.MethodGet: legacy(getOrderMessage, "getOrderMessageLegacy", apiPrefix+"/orders/{order_uid}/raw", orderUID)},
+		"/orders/by-track/{track}":    {http.MethodGet: legacy(byTrack, "getOrderByTrackNumberLegacy", apiPrefix+"/track-numbers/{track_number}/order", pathParam("track", "Трек-номер"))},
+		"/orders/by-transaction/{tx}": {http.MethodGet: legacy(byTransaction, "getOrderByTransactionLegacy", apiPrefix+"/transactions/{transaction}/order", pathParam("tx", "Транзакция оплаты"))},
+	}
+
+	result := openapi3.NewPaths()
+	for path, ops := range paths {
+		item := &openapi3.PathItem{}
+		for method, op := range ops {
+			item.SetOperation(method, op.build())
+		}
+		result.Set(path, item)
+	}
+	return result
+}
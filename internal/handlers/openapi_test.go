@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/broadcast"
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+func TestOpenAPI_Served(t *testing.T) {
+	mux := newTestMux(cache.NewCache(logger.Nop()), repository.NewMemory())
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(w.Body.Bytes())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Errorf("Invalid spec: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/swagger/", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "swagger-ui") {
+		t.Errorf("Expected Swagger UI page, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/swagger/swagger-initializer.js", nil))
+	if !strings.Contains(w.Body.String(), `"/openapi.json"`) {
+		t.Errorf("Swagger UI is not configured for /openapi.json: %s", w.Body)
+	}
+}
+
+// TestOpenAPI_ResponsesMatchSpec прогоняет запросы ко всем операциям
+// и проверяет ответы по спецификации: статус должен быть описан, тело —
+// соответствовать схеме без лишних полей.
+func TestOpenAPI_ResponsesMatchSpec(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	order := &models.Order{
+		OrderUID:    "spec-1",
+		TrackNumber: "TRACK-SPEC",
+		CustomerId:  "spec-customer",
+		DateCreated: base,
+		Delivery:    models.Delivery{Name: "Test Testov", City: "Kiryat Mozkin"},
+		Payment: models.Payment{
+			Transaction: "tx-spec",
+			Currency:    "USD",
+			Amount:      models.NewMoney(1500, "USD"),
+			GoodsTotal:  models.NewMoney(1500, "USD"),
+			PaymentDt:   base,
+		},
+		Items: []models.Items{{Name: "Mascaras", Brand: "Vivienne Sabo", Price: models.NewMoney(1500, "USD"), TotalPrice: models.NewMoney(1500, "USD")}},
+	}
+	msg := &models.OrderMessage{Topic: "order", Key: "spec-1", Payload: json.RawMessage(`{"order_uid":"spec-1"}`), RawPayload: []byte(`{"order_uid":"spec-1"}`), Headers: models.MessageHeaders{models.NewMessageHeader("checksum", []byte{0xff})}, Timestamp: base}
+	if err := repo.SaveWithMessage(ctx, order, msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Save(ctx, &models.Order{OrderUID: "spec-2", DateCreated: base.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	SetupRoutes(mux, cache.NewCache(logger.Nop()), repo, NewIngest(&fakePublisher{}, "order"), broadcast.New(broadcast.DefaultBuffer, logger.Nop()), logger.Nop())
+
+	// Тело потока событий проверяется как строка
+	openapi3filter.RegisterBodyDecoder("text/event-stream", func(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+		data, err := io.ReadAll(body)
+		return string(data), err
+	})
+	defer openapi3filter.UnregisterBodyDecoder("text/event-stream")
+
+	spec, err := openAPISpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Поток событий не завершается сам, поэтому запрос к нему отменен заранее
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/api/v1/orders/spec-1", "", http.StatusOK},
+		{"GET", "/api/v1/orders/spec-2", "", http.StatusOK},
+		{"GET", "/api/v1/orders/missing", "", http.StatusNotFound},
+		{"POST", "/api/v1/orders/spec-1", "", http.StatusMethodNotAllowed},
+		{"GET", "/order/spec-1", "", http.StatusOK},
+		{"GET", "/api/v1/orders/spec-1/raw", "", http.StatusOK},
+		{"GET", "/api/v1/orders/spec-2/raw", "", http.StatusNotFound},
+		{"GET", "/order/spec-1/raw", "", http.StatusOK},
+		{"POST", "/api/v1/orders", validOrderJSON(t, "spec-new"), http.StatusAccepted},
+		{"POST", "/api/v1/orders", validOrderJSON(t, "spec-1"), http.StatusConflict},
+		{"POST", "/api/v1/orders", `{"order_uid": ""}`, http.StatusUnprocessableEntity},
+		{"GET", "/api/v1/orders/spec-new/status", "", http.StatusOK},
+		{"GET", "/api/v1/orders/spec-1/status", "", http.StatusOK},
+		{"GET", "/api/v1/orders/missing/status", "", http.StatusNotFound},
+		{"GET", "/api/v1/orders?limit=1&count=true", "", http.StatusOK},
+		{"GET", "/api/v1/orders?customer_id=nobody", "", http.StatusOK},
+		{"GET", "/api/v1/orders?cursor=broken", "", http.StatusBadRequest},
+		{"GET", "/api/v1/orders/stream?customer_id=spec-customer&last_event_id=1", "", http.StatusOK},
+		{"GET", "/api/v1/orders/stream?last_event_id=abc", "", http.StatusBadRequest},
+		{"POST", "/api/v1/orders:batchGet", `{"order_uids": ["spec-1", "missing"]}`, http.StatusOK},
+		{"POST", "/api/v1/orders:batchGet", `{"order_uids": []}`, http.StatusBadRequest},
+		{"GET", "/api/v1/track-numbers/TRACK-SPEC/order", "", http.StatusOK},
+		{"GET", "/api/v1/track-numbers/missing/order", "", http.StatusNotFound},
+		{"GET", "/api/v1/transactions/tx-spec/order", "", http.StatusOK},
+		{"GET", "/orders/by-track/TRACK-SPEC", "", http.StatusOK},
+		{"GET", "/orders/by-transaction/tx-spec", "", http.StatusOK},
+		{"GET", "/api/v1/customers/spec-customer/orders?limit=1", "", http.StatusOK},
+		{"GET", "/api/v1/customers/nobody/orders", "", http.StatusNotFound},
+		{"GET", "/api/v1/search?q=sabo", "", http.StatusOK},
+		{"GET", "/api/v1/search?q=s", "", http.StatusBadRequest},
+	}
+	covered := make(map[*openapi3.Operation]bool)
+	for _, tc := range cases {
+		name := tc.method + " " + tc.path
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if strings.HasPrefix(tc.path, apiPrefix+"/orders/stream") {
+			req = req.WithContext(canceled)
+		}
+
+		// Маршрут ищем для GET, чтобы 405 проверялся по описанию той же операции
+		lookup := req.Clone(ctx)
+		lookup.Method = http.MethodGet
+		if tc.method == http.MethodPost && tc.status != http.StatusMethodNotAllowed {
+			lookup.Method = http.MethodPost
+		}
+		route, params, err := router.FindRoute(lookup)
+		if err != nil {
+			t.Errorf("%s: route not described in spec: %v", name, err)
+			continue
+		}
+		covered[route.Operation] = true
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.status, w.Code, w.Body)
+			continue
+		}
+
+		input := &openapi3filter.RequestValidationInput{Request: lookup, PathParams: params, Route: route}
+		if tc.body != "" {
+			lookup.Body = io.NopCloser(strings.NewReader(tc.body))
+		}
+		if tc.status < 400 {
+			if err := openapi3filter.ValidateRequest(ctx, input); err != nil {
+				t.Errorf("%s: request does not match spec: %v", name, err)
+			}
+		}
+		err = openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 w.Code,
+			Header:                 w.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+			Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+		})
+		if err != nil {
+			t.Errorf("%s: response does not match spec: %v", name, err)
+		}
+	}
+
+	for path, item := range doc.Paths.Map() {
+		for method, op := range item.Operations() {
+			if !covered[op] {
+				t.Errorf("%s %s is not covered by the test", method, path)
+			}
+		}
+	}
+}
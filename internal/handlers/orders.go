@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type orderList struct {
+	Orders     []models.Order `json:"orders"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Total      *int64         `json:"total,omitempty"`
+}
+
+// parseOrderQuery разбирает параметры GET /api/v1/orders:
+// limit, cursor, customer_id, track_number, delivery_service, locale, brand,
+// created_from, created_to (RFC 3339) и count=true для подсчета общего числа.
+func parseOrderQuery(values url.Values) (repository.OrderQuery, error) {
+	q := repository.OrderQuery{
+		Cursor: values.Get("cursor"),
+		Filter: repository.OrderFilter{
+			CustomerID:      values.Get("customer_id"),
+			TrackNumber:     values.Get("track_number"),
+			DeliveryService: values.Get("delivery_service"),
+			Locale:          values.Get("locale"),
+			Brand:           values.Get("brand"),
+		},
+	}
+
+	var err error
+	if q.Limit, err = parseLimit(values); err != nil {
+		return q, err
+	}
+	if v := values.Get("count"); v != "" {
+		count, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, fmt.Errorf("count must be true or false")
+		}
+		q.WithTotal = count
+	}
+
+	if q.Filter.CreatedFrom, err = parseTime(values, "created_from"); err != nil {
+		return q, err
+	}
+	if q.Filter.CreatedTo, err = parseTime(values, "created_to"); err != nil {
+		return q, err
+	}
+	return q, q.Filter.Validate()
+}
+
+func parseLimit(values url.Values) (int, error) {
+	v := values.Get("limit")
+	if v == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 || limit > repository.MaxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", repository.MaxPageSize)
+	}
+	return limit, nil
+}
+
+func parseTime(values url.Values, name string) (time.Time, error) {
+	v := values.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return t, nil
+}
+
+func listOrders(ctx context.Context, w http.ResponseWriter, values url.Values, repo repository.OrderRepository, log *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "handlers.listOrders",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPRoute(apiPrefix+"/orders")),
+	)
+	defer span.End()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+	q, err := parseOrderQuery(values)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	page, err := repo.Find(ctx, q)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidCursor, "Invalid cursor")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Error("list orders failed", "error", err)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
+		return
+	}
+	span.SetAttributes(attribute.Int("orders.count", len(page.Orders)))
+
+	if page.Orders == nil {
+		page.Orders = []models.Order{}
+	}
+	json.NewEncoder(w).Encode(orderList{Orders: page.Orders, NextCursor: page.NextCursor, Total: page.Total})
+}
+
+type customerSummary struct {
+	OrderCount   int64          `json:"order_count"`
+	TotalSpent   []models.Money `json:"total_spent"`
+	FirstOrderAt time.Time      `json:"first_order_at"`
+	LastOrderAt  time.Time      `json:"last_order_at"`
+}
+
+type customerOrders struct {
+	CustomerID string          `json:"customer_id"`
+	Summary    customerSummary `json:"summary"`
+	orderList
+}
+
+// listCustomerOrders отдает заказы клиента от новых к старым (limit, cursor)
+// и сводку по всем его заказам.
+func listCustomerOrders(ctx context.Context, w http.ResponseWriter, customerID string, values url.Values, repo repository.OrderRepository, log *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "handlers.listCustomerOrders",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRoute(apiPrefix+"/customers/{customer_id}/orders"),
+			attribute.String("customer.id", customerID),
+		),
+	)
+	defer span.End()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+	limit, err := parseLimit(values)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	summary, err := repo.CustomerSummary(ctx, customerID)
+	if err == nil && summary.OrderCount == 0 {
+		log.Info("customer has no orders")
+		writeProblem(w, http.StatusNotFound, CodeCustomerNotFound, "Customer not found")
+		return
+	}
+	var page repository.OrderPage
+	if err == nil {
+		page, err = repo.Find(ctx, repository.OrderQuery{
+			Filter: repository.OrderFilter{CustomerID: customerID},
+			Limit:  limit,
+			Cursor: values.Get("cursor"),
+		})
+	}
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidCursor, "Invalid cursor")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Error("list customer orders failed", "error", err)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
+		return
+	}
+
+	resp := customerOrders{
+		CustomerID: customerID,
+		Summary: customerSummary{
+			OrderCount:   summary.OrderCount,
+			TotalSpent:   summary.TotalSpent,
+			FirstOrderAt: summary.FirstOrderAt,
+			LastOrderAt:  summary.LastOrderAt,
+		},
+		orderList: orderList{Orders: page.Orders, NextCursor: page.NextCursor},
+	}
+	if resp.Summary.TotalSpent == nil {
+		resp.Summary.TotalSpent = []models.Money{}
+	}
+	if resp.Orders == nil {
+		resp.Orders = []models.Order{}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
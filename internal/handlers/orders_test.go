@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+)
+
+func TestListOrders(t *testing.T) {
+	repo := repository.NewMemory()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, uid := range []string{"o1", "o2", "o3"} {
+		order := &models.Order{OrderUID: uid, CustomerId: "c1", DateCreated: base.Add(time.Duration(i) * time.Hour)}
+		if uid == "o2" {
+			order.CustomerId = "c2"
+		}
+		if err := repo.Save(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := newTestMux(cache.NewCache(logger.Nop()), repo)
+
+	req := httptest.NewRequest("GET", "/api/v1/orders?customer_id=c1&limit=1&count=true", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var list orderList
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(list.Orders) != 1 || list.Orders[0].OrderUID != "o3" {
+		t.Fatalf("Expected newest order o3, got %+v", list.Orders)
+	}
+	if list.Total == nil || *list.Total != 2 {
+		t.Errorf("Expected total 2, got %v", list.Total)
+	}
+	if list.NextCursor == "" {
+		t.Fatal("Expected next cursor")
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/orders?customer_id=c1&limit=1&cursor="+list.NextCursor, nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	list = orderList{}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(list.Orders) != 1 || list.Orders[0].OrderUID != "o1" || list.NextCursor != "" {
+		t.Errorf("Expected last page with o1, got %+v", list)
+	}
+}
+
+func TestListOrders_InvalidParams(t *testing.T) {
+	mux := newTestMux(cache.NewCache(logger.Nop()), repository.NewMemory())
+
+	for _, query := range []string{
+		"limit=0",
+		"limit=abc",
+		"limit=1000",
+		"count=maybe",
+		"created_from=yesterday",
+		"created_from=2024-02-01T00:00:00Z&created_to=2024-01-01T00:00:00Z",
+		"cursor=broken",
+	} {
+		req := httptest.NewRequest("GET", "/api/v1/orders?"+query, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestListOrders_Empty(t *testing.T) {
+	mux := newTestMux(cache.NewCache(logger.Nop()), repository.NewMemory())
+
+	req := httptest.NewRequest("GET", "/api/v1/orders", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var body map[string]json.RawMessage
+	json.NewDecoder(w.Body).Decode(&body)
+	if string(body["orders"]) != "[]" {
+		t.Errorf("Expected empty orders array, got %s", body["orders"])
+	}
+}
+
+func TestGetOrderByTrackAndTransaction(t *testing.T) {
+	c := cache.NewCache(logger.Nop())
+	repo := repository.NewMemory()
+	cached := &models.Order{OrderUID: "cached", TrackNumber: "TRACK-C", Payment: models.Payment{Transaction: "tx-c"}}
+	c.Set(cached.OrderUID, cached)
+	stored := &models.Order{OrderUID: "stored", TrackNumber: "TRACK-S", Payment: models.Payment{Transaction: "tx-s"}}
+	if err := repo.Save(context.Background(), stored); err != nil {
+		t.Fatal(err)
+	}
+	mux := newTestMux(c, repo)
+
+	cases := map[string]struct {
+		code int
+		uid  string
+	}{
+		"/api/v1/track-numbers/TRACK-C/order": {http.StatusOK, "cached"},
+		"/api/v1/transactions/tx-c/order":     {http.StatusOK, "cached"},
+		"/api/v1/track-numbers/TRACK-S/order": {http.StatusOK, "stored"},
+		"/api/v1/transactions/tx-s/order":     {http.StatusOK, "stored"},
+		"/api/v1/track-numbers/missing/order": {http.StatusNotFound, ""},
+		"/api/v1/transactions/missing/order":  {http.StatusNotFound, ""},
+		"/orders/by-track/TRACK-C":            {http.StatusOK, "cached"},
+		"/orders/by-transaction/tx-s":         {http.StatusOK, "stored"},
+	}
+	for path, tc := range cases {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+		if w.Code != tc.code {
+			t.Errorf("%s: expected %d, got %d", path, tc.code, w.Code)
+			continue
+		}
+		if tc.uid == "" {
+			continue
+		}
+		var order models.Order
+		if err := json.NewDecoder(w.Body).Decode(&order); err != nil || order.OrderUID != tc.uid {
+			t.Errorf("%s: expected order %s, got %+v (%v)", path, tc.uid, order, err)
+		}
+	}
+
+	// Заказ из базы попадает в кэш и доступен по индексу
+	if _, ok := c.GetByTransaction("tx-s"); !ok {
+		t.Error("Order loaded by transaction was not cached")
+	}
+}
+
+func TestListCustomerOrders(t *testing.T) {
+	repo := repository.NewMemory()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	payments := []models.Payment{
+		{Currency: "USD", Amount: models.NewMoney(1000, "USD")},
+		{Currency: "RUB", Amount: models.NewMoney(50000, "RUB")},
+		{Currency: "USD", Amount: models.NewMoney(250, "USD")},
+	}
+	for i, p := range payments {
+		order := &models.Order{
+			OrderUID:    fmt.Sprintf("c-%d", i),
+			CustomerId:  "customer",
+			DateCreated: base.Add(time.Duration(i) * 24 * time.Hour),
+			Payment:     p,
+		}
+		if err := repo.Save(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := newTestMux(cache.NewCache(logger.Nop()), repo)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/customers/customer/orders?limit=2", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp customerOrders
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(resp.Orders) != 2 || resp.Orders[0].OrderUID != "c-2" || resp.NextCursor == "" {
+		t.Errorf("Expected first page with c-2 and cursor, got %+v", resp.orderList)
+	}
+	s := resp.Summary
+	if s.OrderCount != 3 {
+		t.Errorf("Expected 3 orders, got %d", s.OrderCount)
+	}
+	want := []models.Money{models.NewMoney(50000, "RUB"), models.NewMoney(1250, "USD")}
+	if len(s.TotalSpent) != 2 || s.TotalSpent[0] != want[0] || s.TotalSpent[1] != want[1] {
+		t.Errorf("Expected totals %v, got %v", want, s.TotalSpent)
+	}
+	if !s.FirstOrderAt.Equal(base) || !s.LastOrderAt.Equal(base.Add(48*time.Hour)) {
+		t.Errorf("Unexpected dates: %v - %v", s.FirstOrderAt, s.LastOrderAt)
+	}
+
+	for path, code := range map[string]int{
+		"/api/v1/customers/nobody/orders":             http.StatusNotFound,
+		"/api/v1/customers/customer/orders?limit=0":   http.StatusBadRequest,
+		"/api/v1/customers/customer/orders?cursor=xx": http.StatusBadRequest,
+		"/api/v1/customers/customer":                  http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != code {
+			t.Errorf("%s: expected %d, got %d", path, code, w.Code)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const problemContentType = "application/problem+json"
+
+// Коды ошибок API. Клиенты должны опираться на code, а не на текст detail.
+const (
+	CodeNotFound                 = "not_found"
+	CodeMethodNotAllowed         = "method_not_allowed"
+	CodeInvalidParameter         = "invalid_parameter"
+	CodeInvalidCursor            = "invalid_cursor"
+	CodeInvalidRequestBody       = "invalid_request_body"
+	CodeOrderNotFound            = "order_not_found"
+	CodeOrderMessageNotFound     = "order_message_not_found"
+	CodeCustomerNotFound         = "customer_not_found"
+	CodeInvalidOrder             = "invalid_order"
+	CodeOrderExists              = "order_exists"
+	CodeIdempotencyKeyInProgress = "idempotency_key_in_progress"
+	CodeIdempotencyKeyReused     = "idempotency_key_reused"
+	CodePublishFailed            = "publish_failed"
+	CodeInternalError            = "internal_error"
+)
+
+// Problem — тело ошибки в формате RFC 7807 (application/problem+json)
+// с дополнительным полем code. Поле type всегда about:blank, поэтому
+// title совпадает с текстом HTTP статуса.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+}
+
+func writeProblem(w http.ResponseWriter, status int, code, detail string) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	})
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	minSearchQuery = 2
+	maxSearchQuery = 100
+)
+
+type searchMatch struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+	// Highlight — значение, экранированное для HTML, с совпадениями в <mark>.
+	Highlight string `json:"highlight"`
+}
+
+type searchResult struct {
+	Order   models.Order  `json:"order"`
+	Score   float64       `json:"score"`
+	Matches []searchMatch `json:"matches"`
+}
+
+type searchResponse struct {
+	Query   string         `json:"query"`
+	Results []searchResult `json:"results"`
+}
+
+func searchOrders(ctx context.Context, w http.ResponseWriter, values url.Values, repo repository.OrderRepository, log *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "handlers.searchOrders",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPRoute(apiPrefix+"/search")),
+	)
+	defer span.End()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+	query := strings.TrimSpace(values.Get("q"))
+	if n := utf8.RuneCountInString(query); n < minSearchQuery || n > maxSearchQuery {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "q must be between 2 and 100 characters")
+		return
+	}
+	limit, err := parseLimit(values)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = repository.DefaultPageSize
+	}
+
+	results, err := repo.Search(ctx, query, limit)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Error("search orders failed", "error", err)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
+		return
+	}
+	span.SetAttributes(attribute.Int("search.results", len(results)))
+
+	resp := searchResponse{Query: query, Results: make([]searchResult, 0, len(results))}
+	for _, r := range results {
+		result := searchResult{Order: r.Order, Score: r.Score}
+		for _, m := range r.Matches {
+			result.Matches = append(result.Matches, searchMatch{
+				Field:     m.Field,
+				Value:     m.Value,
+				Highlight: highlight(m.Value, query),
+			})
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// highlight экранирует value для HTML и оборачивает в <mark> вхождения
+// слов запроса без учета регистра. Нечеткие совпадения не подсвечиваются.
+func highlight(value, query string) string {
+	lower := strings.ToLower(value)
+	// ToLower может изменить длину строки в байтах, тогда подсветка невозможна.
+	if len(lower) != len(value) {
+		return html.EscapeString(value)
+	}
+
+	marked := make([]bool, len(value))
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		for start := 0; ; {
+			i := strings.Index(lower[start:], word)
+			if i < 0 {
+				break
+			}
+			for j := start + i; j < start+i+len(word); j++ {
+				marked[j] = true
+			}
+			start += i + len(word)
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); {
+		j := i
+		for j < len(value) && marked[j] == marked[i] {
+			j++
+		}
+		if marked[i] {
+			b.WriteString("<mark>" + html.EscapeString(value[i:j]) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(value[i:j]))
+		}
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+)
+
+func TestHighlight(t *testing.T) {
+	cases := []struct {
+		value, query, want string
+	}{
+		{"Vivienne Sabo", "sabo", "Vivienne <mark>Sabo</mark>"},
+		{"Vivienne Sabo", "viv sab", "<mark>Viv</mark>ienne <mark>Sab</mark>o"},
+		{"Кирьят Моцкин", "моц", "Кирьят <mark>Моц</mark>кин"},
+		{"<b>Bold</b>", "bold", "&lt;b&gt;<mark>Bold</mark>&lt;/b&gt;"},
+		{"Mascaras", "mascra", "Mascaras"},
+	}
+	for _, tc := range cases {
+		if got := highlight(tc.value, tc.query); got != tc.want {
+			t.Errorf("highlight(%q, %q): expected %q, got %q", tc.value, tc.query, tc.want, got)
+		}
+	}
+}
+
+func TestSearchOrders(t *testing.T) {
+	repo := repository.NewMemory()
+	orders := []*models.Order{
+		{OrderUID: "brand", Items: []models.Items{{Name: "Mascaras", Brand: "Vivienne Sabo"}}},
+		{OrderUID: "city", Delivery: models.Delivery{Name: "Test Testov", City: "Saboteur City Center"}},
+		{OrderUID: "other", Delivery: models.Delivery{City: "Moscow"}},
+	}
+	for _, order := range orders {
+		if err := repo.Save(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := newTestMux(cache.NewCache(logger.Nop()), repo)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/search?q=sabo", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp searchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", resp.Results)
+	}
+	// Короткое значение с совпадением ранжируется выше
+	first := resp.Results[0]
+	if first.Order.OrderUID != "brand" || first.Score <= resp.Results[1].Score {
+		t.Errorf("Expected brand match first, got %+v", resp.Results)
+	}
+	if len(first.Matches) != 1 || first.Matches[0].Field != repository.FieldItemBrand ||
+		first.Matches[0].Highlight != "Vivienne <mark>Sabo</mark>" {
+		t.Errorf("Unexpected matches: %+v", first.Matches)
+	}
+
+	for _, query := range []string{"", "q=a", "q=sabo&limit=0"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/search?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/broadcast"
+)
+
+const (
+	// streamRetry — через сколько EventSource переподключается после обрыва.
+	streamRetry = 3 * time.Second
+	// streamHeartbeat — интервал комментариев, не дающих прокси закрыть
+	// соединение без событий.
+	streamHeartbeat = 15 * time.Second
+	// streamWriteTimeout ограничивает запись одного события: клиент, который
+	// не читает ответ, не должен держать обработчик бесконечно.
+	streamWriteTimeout = 10 * time.Second
+)
+
+const (
+	streamEventOrder = "order"
+	// streamEventReset означает, что пропущенные события восстановить нельзя
+	// и клиенту нужно перечитать список заказов.
+	streamEventReset = "reset"
+)
+
+// parseLastEventID берет номер последнего полученного события из заголовка
+// Last-Event-ID, который EventSource отправляет при переподключении, или из
+// параметра last_event_id для первого подключения.
+func parseLastEventID(r *http.Request) (uint64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid last event ID %q", v)
+	}
+	return id, nil
+}
+
+// streamOrders отдает сохраненные заказы как Server-Sent Events с фильтрами
+// customer_id и delivery_service. После переподключения с Last-Event-ID
+// сначала отправляются пропущенные события из истории. Клиент, который не
+// успевает читать поток, отключается и догоняет при переподключении.
+func streamOrders(ctx context.Context, w http.ResponseWriter, r *http.Request, broadcaster *broadcast.Broadcaster, log *slog.Logger) {
+	lastID, err := parseLastEventID(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+	filter := broadcast.Filter{
+		CustomerID:      r.URL.Query().Get("customer_id"),
+		DeliveryService: r.URL.Query().Get("delivery_service"),
+	}
+	log = log.With("customer_id", filter.CustomerID, "delivery_service", filter.DeliveryService)
+
+	sub, replay, complete := broadcaster.SubscribeFrom(filter, lastID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	write := func(format string, args ...any) error {
+		// Запись без поддержки дедлайнов (например, в тестах) не ограничивается
+		rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	send := func(event broadcast.Event) error {
+		data, err := json.Marshal(event.Order)
+		if err != nil {
+			return err
+		}
+		return write("id: %d\nevent: %s\ndata: %s\n\n", event.ID, streamEventOrder, data)
+	}
+
+	if err := write("retry: %d\n\n", streamRetry.Milliseconds()); err != nil {
+		return
+	}
+	if !complete {
+		log.Info("order stream history lost", "last_event_id", lastID)
+		if err := write("event: %s\ndata: {}\n\n", streamEventReset); err != nil {
+			return
+		}
+	}
+	for _, event := range replay {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+	log.Debug("order stream started", "replayed", len(replay))
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug("order stream closed by client")
+			return
+		case <-heartbeat.C:
+			if err := write(": keepalive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				if sub.Dropped() {
+					log.Warn("slow order stream client disconnected")
+				}
+				return
+			}
+			if err := send(event); err != nil {
+				log.Debug("write order event failed", "error", err)
+				return
+			}
+		}
+	}
+}
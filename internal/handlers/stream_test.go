@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gegxkss/wbL0/internal/broadcast"
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+)
+
+type sseEvent struct {
+	id, event, data string
+}
+
+type sseClient struct {
+	resp   *http.Response
+	reader *bufio.Reader
+}
+
+// openStream подключается к потоку и дочитывает первый блок с retry,
+// после которого подписка на сервере уже оформлена.
+func openStream(t *testing.T, srv *httptest.Server, path, lastEventID string) *sseClient {
+	t.Helper()
+	req, err := http.NewRequest("GET", srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	c := &sseClient{resp: resp, reader: bufio.NewReader(resp.Body)}
+	if line, err := c.reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, "retry: ") {
+		t.Fatalf("Expected retry field, got %q (%v)", line, err)
+	}
+	c.reader.ReadString('\n')
+	return c
+}
+
+// next читает следующее событие, пропуская комментарии.
+func (c *sseClient) next() (sseEvent, error) {
+	var e sseEvent
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return e, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if e != (sseEvent{}) {
+				return e, nil
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			e.id = value
+		case "event":
+			e.event = value
+		case "data":
+			e.data = value
+		}
+	}
+}
+
+func (c *sseClient) nextOrder(t *testing.T) (sseEvent, models.Order) {
+	t.Helper()
+	e, err := c.next()
+	if err != nil {
+		t.Fatalf("Read event failed: %v", err)
+	}
+	var order models.Order
+	if e.event != streamEventOrder || json.Unmarshal([]byte(e.data), &order) != nil {
+		t.Fatalf("Expected order event, got %+v", e)
+	}
+	return e, order
+}
+
+func newStreamServer(t *testing.T) (*httptest.Server, *broadcast.Broadcaster) {
+	t.Helper()
+	b := broadcast.New(broadcast.DefaultBuffer, logger.Nop())
+	mux := http.NewServeMux()
+	SetupRoutes(mux, cache.NewCache(logger.Nop()), repository.NewMemory(), nil, b, logger.Nop())
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	t.Cleanup(b.Close)
+	return srv, b
+}
+
+func TestStreamOrders(t *testing.T) {
+	srv, b := newStreamServer(t)
+	stream := openStream(t, srv, "/api/v1/orders/stream?customer_id=alice", "")
+
+	b.Publish(&models.Order{OrderUID: "bob-1", CustomerId: "bob"})
+	b.Publish(&models.Order{OrderUID: "alice-1", CustomerId: "alice"})
+	first, order := stream.nextOrder(t)
+	if order.OrderUID != "alice-1" {
+		t.Errorf("Expected only alice's orders, got %q", order.OrderUID)
+	}
+	stream.resp.Body.Close()
+
+	// Пропущенные за время переподключения заказы досылаются из истории
+	b.Publish(&models.Order{OrderUID: "alice-2", CustomerId: "alice"})
+	b.Publish(&models.Order{OrderUID: "bob-2", CustomerId: "bob"})
+	resumed := openStream(t, srv, "/api/v1/orders/stream?customer_id=alice", first.id)
+	b.Publish(&models.Order{OrderUID: "alice-3", CustomerId: "alice"})
+
+	var got []string
+	var ids []uint64
+	for range 2 {
+		e, order := resumed.nextOrder(t)
+		got = append(got, order.OrderUID)
+		id, _ := strconv.ParseUint(e.id, 10, 64)
+		ids = append(ids, id)
+	}
+	if strings.Join(got, ",") != "alice-2,alice-3" {
+		t.Errorf("Expected alice-2,alice-3 after reconnect, got %v", got)
+	}
+	if ids[1] <= ids[0] {
+		t.Errorf("Expected increasing event IDs, got %v", ids)
+	}
+
+	// Остановка рассылки завершает поток
+	b.Close()
+	if _, err := resumed.next(); err != io.EOF {
+		t.Errorf("Expected stream to end after Close, got %v", err)
+	}
+}
+
+func TestStreamOrders_LostHistory(t *testing.T) {
+	srv, b := newStreamServer(t)
+
+	stream := openStream(t, srv, "/api/v1/orders/stream", "42")
+	if e, err := stream.next(); err != nil || e.event != streamEventReset {
+		t.Fatalf("Expected reset event, got %+v (%v)", e, err)
+	}
+	b.Publish(&models.Order{OrderUID: "uid-1"})
+	if _, order := stream.nextOrder(t); order.OrderUID != "uid-1" {
+		t.Errorf("Expected live order after reset, got %q", order.OrderUID)
+	}
+}
+
+func TestStreamOrders_InvalidLastEventID(t *testing.T) {
+	srv, _ := newStreamServer(t)
+
+	resp, err := srv.Client().Get(srv.URL + "/api/v1/orders/stream?last_event_id=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || resp.Header.Get("Content-Type") != problemContentType {
+		t.Errorf("Expected 400 problem, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// Без broadcaster поток не регистрируется, а путь остается заказом
+	w := httptest.NewRecorder()
+	newTestMux(cache.NewCache(logger.Nop()), repository.NewMemory()).
+		ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/orders/stream", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without broadcaster, got %d", w.Code)
+	}
+}
+
+func TestParseLastEventID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/orders/stream?last_event_id=5", nil)
+	if id, err := parseLastEventID(req); err != nil || id != 5 {
+		t.Errorf("Expected 5 from query, got %d (%v)", id, err)
+	}
+	req.Header.Set("Last-Event-ID", "7")
+	if id, err := parseLastEventID(req); err != nil || id != 7 {
+		t.Errorf("Expected header to take precedence, got %d (%v)", id, err)
+	}
+}
@@ -0,0 +1,238 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	checkTimeout = 2 * time.Second
+)
+
+// CheckFunc возвращает ошибку, если компонент не готов обслуживать запросы.
+type CheckFunc func(ctx context.Context) error
+
+type ComponentStatus struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	Version    string  `json:"version,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+	Details    any     `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+type StatusReport struct {
+	Report
+	Version       string            `json:"version"`
+	GoVersion     string            `json:"go_version"`
+	StartedAt     time.Time         `json:"started_at"`
+	UptimeSeconds float64           `json:"uptime_seconds"`
+	Dependencies  map[string]string `json:"dependencies,omitempty"`
+}
+
+type component struct {
+	name    string
+	check   CheckFunc
+	version string
+	details func() any
+}
+
+// Checker собирает проверки компонентов сервиса для /readyz и /status.
+type Checker struct {
+	version    string
+	startedAt  time.Time
+	mu         sync.RWMutex
+	components []*component
+}
+
+func NewChecker(version string) *Checker {
+	return &Checker{
+		version:   version,
+		startedAt: time.Now(),
+	}
+}
+
+// Register добавляет проверку компонента. Повторная регистрация заменяет проверку.
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if comp := c.find(name); comp != nil {
+		comp.check = check
+		return
+	}
+	c.components = append(c.components, &component{name: name, check: check})
+}
+
+// SetVersion задает версию компонента, которая выводится в /status.
+func (c *Checker) SetVersion(name, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if comp := c.find(name); comp != nil {
+		comp.version = version
+		return
+	}
+	c.components = append(c.components, &component{name: name, version: version})
+}
+
+// SetDetails задает функцию, данные которой выводятся в /status для компонента,
+// например статистику пула соединений.
+func (c *Checker) SetDetails(name string, details func() any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if comp := c.find(name); comp != nil {
+		comp.details = details
+		return
+	}
+	c.components = append(c.components, &component{name: name, details: details})
+}
+
+func (c *Checker) find(name string) *component {
+	for _, comp := range c.components {
+		if comp.name == name {
+			return comp
+		}
+	}
+	return nil
+}
+
+// Check выполняет все проверки параллельно.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	components := make([]component, len(c.components))
+	for i, comp := range c.components {
+		components[i] = *comp
+	}
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	results := make([]ComponentStatus, len(components))
+	var wg sync.WaitGroup
+	for i, comp := range components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, comp)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Components: make(map[string]ComponentStatus, len(components))}
+	for i, comp := range components {
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+		report.Components[comp.name] = results[i]
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, comp component) ComponentStatus {
+	status := ComponentStatus{Status: StatusUp, Version: comp.version}
+	if comp.check == nil {
+		return status
+	}
+
+	started := time.Now()
+	err := comp.check(ctx)
+	status.DurationMs = float64(time.Since(started).Microseconds()) / 1000
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// Routes регистрирует /healthz, /readyz и /status.
+func (c *Checker) Routes(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", c.handleLiveness)
+	mux.HandleFunc("/readyz", c.handleReadiness)
+	mux.HandleFunc("/status", c.handleStatus)
+}
+
+func (c *Checker) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusUp})
+}
+
+func (c *Checker) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+	writeJSON(w, httpStatus(report), report)
+}
+
+func (c *Checker) handleStatus(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+	c.addDetails(report)
+	writeJSON(w, http.StatusOK, StatusReport{
+		Report:        report,
+		Version:       c.version,
+		GoVersion:     runtime.Version(),
+		StartedAt:     c.startedAt,
+		UptimeSeconds: time.Since(c.startedAt).Seconds(),
+		Dependencies:  dependencies(),
+	})
+}
+
+func (c *Checker) addDetails(report Report) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, comp := range c.components {
+		if comp.details == nil {
+			continue
+		}
+		status := report.Components[comp.name]
+		status.Details = comp.details()
+		report.Components[comp.name] = status
+	}
+}
+
+func httpStatus(report Report) int {
+	if report.Status != StatusUp {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// dependencies возвращает версии основных библиотек из информации о сборке.
+func dependencies() map[string]string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	wanted := map[string]bool{
+		"github.com/segmentio/kafka-go": true,
+		"gorm.io/gorm":                  true,
+		"gorm.io/driver/postgres":       true,
+		"github.com/jackc/pgx/v5":       true,
+	}
+	deps := make(map[string]string)
+	for _, dep := range info.Deps {
+		if wanted[dep.Path] {
+			deps[dep.Path] = dep.Version
+		}
+	}
+	return deps
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
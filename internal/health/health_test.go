@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLiveness(t *testing.T) {
+	mux := http.NewServeMux()
+	NewChecker("test").Routes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+}
+
+func TestReadinessFailsWhenComponentDown(t *testing.T) {
+	c := NewChecker("test")
+	c.Register("database", func(context.Context) error { return nil })
+	c.Register("kafka", func(context.Context) error { return errors.New("no brokers") })
+	mux := http.NewServeMux()
+	c.Routes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d", w.Code)
+	}
+	var report Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if report.Components["database"].Status != StatusUp {
+		t.Errorf("Expected database up, got %+v", report.Components["database"])
+	}
+	if got := report.Components["kafka"]; got.Status != StatusDown || got.Error != "no brokers" {
+		t.Errorf("Expected kafka down, got %+v", got)
+	}
+}
+
+func TestStatusIncludesVersions(t *testing.T) {
+	c := NewChecker("1.2.3")
+	c.Register("database", func(context.Context) error { return nil })
+	c.SetVersion("database", "15.4")
+	mux := http.NewServeMux()
+	c.Routes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+	var report StatusReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if report.Version != "1.2.3" {
+		t.Errorf("Expected version 1.2.3, got %q", report.Version)
+	}
+	if report.Components["database"].Version != "15.4" {
+		t.Errorf("Expected database version 15.4, got %+v", report.Components["database"])
+	}
+}
+
+func TestStatusIncludesDetails(t *testing.T) {
+	c := NewChecker("test")
+	c.Register("database", func(context.Context) error { return nil })
+	c.SetDetails("database", func() any { return map[string]int{"open": 3} })
+	mux := http.NewServeMux()
+	c.Routes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+
+	var report struct {
+		Components map[string]struct {
+			Details map[string]int `json:"details"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if report.Components["database"].Details["open"] != 3 {
+		t.Errorf("Expected pool details in status, got %+v", report.Components["database"])
+	}
+}
@@ -0,0 +1,56 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type hook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Lifecycle останавливает компоненты приложения в порядке их регистрации:
+// сначала то, что принимает работу извне (HTTP, Kafka), затем хранилища.
+type Lifecycle struct {
+	mu    sync.Mutex
+	hooks []hook
+	log   *slog.Logger
+}
+
+func New(log *slog.Logger) *Lifecycle {
+	return &Lifecycle{log: log.With("component", "lifecycle")}
+}
+
+// OnStop добавляет шаг остановки.
+func (l *Lifecycle) OnStop(name string, stop func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook{name: name, stop: stop})
+}
+
+// Shutdown последовательно выполняет все шаги остановки. Ошибка одного шага
+// не прерывает остальные; если ctx истек, оставшимся шагам тоже передается
+// истекший контекст, чтобы они закрыли ресурсы без ожидания.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.hooks
+	l.hooks = nil
+	l.mu.Unlock()
+
+	var errs []error
+	for _, h := range hooks {
+		started := time.Now()
+		if err := h.stop(ctx); err != nil {
+			l.log.Error("stop failed", "step", h.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		l.log.Info("stopped", "step", h.name, "duration", time.Since(started))
+	}
+	return errors.Join(errs...)
+}
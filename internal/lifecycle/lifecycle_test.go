@@ -0,0 +1,47 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gegxkss/wbL0/internal/logger"
+)
+
+func TestShutdownRunsHooksInOrder(t *testing.T) {
+	l := New(logger.Nop())
+	var order []string
+	for _, name := range []string{"http", "consumer", "database"} {
+		l.OnStop(name, func(context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if want := []string{"http", "consumer", "database"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected %v, got %v", want, order)
+	}
+}
+
+func TestShutdownContinuesAfterError(t *testing.T) {
+	l := New(logger.Nop())
+	failure := errors.New("boom")
+	closed := false
+	l.OnStop("consumer", func(context.Context) error { return failure })
+	l.OnStop("database", func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	err := l.Shutdown(context.Background())
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected error to wrap %v, got %v", failure, err)
+	}
+	if !closed {
+		t.Error("Database step was not run")
+	}
+}
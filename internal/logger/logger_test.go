@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"":      slog.LevelInfo,
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	}
+	for in, want := range cases {
+		got, err := ParseLevel(in)
+		if err != nil {
+			t.Fatalf("ParseLevel(%q) failed: %v", in, err)
+		}
+		if got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", in, got, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+}
+
+func TestNewWritesJSONAndFiltersLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(&buf, "info")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	log.Debug("cache hit", "order_uid", "a")
+	log.Info("order saved", "order_uid", "b")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a single JSON entry, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "order saved" || entry["order_uid"] != "b" {
+		t.Errorf("Unexpected entry: %v", entry)
+	}
+}
@@ -1,17 +1,39 @@
 package models
 
+import "gorm.io/gorm"
+
 type Items struct {
 	ID          uint   `gorm:"primaryKey;autoIncrement:true" json:"-"`
 	OrderUID    string `gorm:"not null;column:order_uid" json:"-"`
 	ChrtId      int    `json:"chrt_id"`
 	Tracknumber string `gorm:"column:track_number" json:"track_number"`
-	Price       int    `json:"price"`
+	Price       Money  `json:"price"`
 	Rid         string `json:"rid"`
 	Name        string `json:"name"`
 	Sale        int    `json:"sale"`
 	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
+	TotalPrice  Money  `json:"total_price"`
 	NmId        int    `json:"nm_id"`
 	Brand       string `json:"brand"`
 	Status      int    `json:"status"`
+	// Currency хранит валюту Price и TotalPrice в колонке items.currency.
+	Currency string `json:"-"`
+}
+
+// BeforeSave записывает валюту сумм в колонку currency.
+func (i *Items) BeforeSave(*gorm.DB) error {
+	if i.Currency == "" {
+		i.Currency = i.Price.Currency
+	}
+	if i.Currency == "" {
+		i.Currency = i.TotalPrice.Currency
+	}
+	return nil
+}
+
+// AfterFind восстанавливает валюту сумм из колонки currency.
+func (i *Items) AfterFind(*gorm.DB) error {
+	i.Price.Currency = i.Currency
+	i.TotalPrice.Currency = i.Currency
+	return nil
 }
@@ -6,12 +6,12 @@ func TestItemsStruct(t *testing.T) {
 	item := Items{
 		ChrtId: 1,
 		Tracknumber: "track",
-		Price: 100,
+		Price: NewMoney(10000, "RUB"),
 		Rid: "rid",
 		Name: "item",
 		Sale: 10,
 		Size: "M",
-		TotalPrice: 90,
+		TotalPrice: NewMoney(9000, "RUB"),
 		NmId: 123,
 		Brand: "brand",
 		Status: 1,
@@ -19,7 +19,7 @@ func TestItemsStruct(t *testing.T) {
 	if item.Name != "item" {
 		t.Error("Name not set")
 	}
-	if item.Price != 100 {
+	if item.Price != NewMoney(10000, "RUB") {
 		t.Error("Price not set")
 	}
 	if item.Brand != "brand" {
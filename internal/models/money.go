@@ -0,0 +1,269 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// ErrUnresolvedMoney — сумма старого формата не пересчитана, потому что
+// валюта неизвестна (например, Items разобран отдельно от заказа).
+var ErrUnresolvedMoney = errors.New("legacy amount without currency was not converted to minor units")
+
+// defaultExponent — число знаков после запятой для валют, которых нет в currencyExponents.
+const defaultExponent = 2
+
+// currencyExponents — валюты ISO 4217, у которых число знаков отличается от двух.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent возвращает число знаков дробной части валюты (2 для USD, 0 для JPY).
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return defaultExponent
+}
+
+// ValidCurrency проверяет, что код похож на ISO 4217: три латинские буквы.
+func ValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Money — сумма в минимальных единицах валюты (центах, копейках) и код валюты ISO 4217.
+// В базе хранится только сумма, валюта берется из payments.currency / items.currency.
+//
+// В JSON сумма выводится объектом {"amount": 181700, "currency": "USD", "decimal": "1817.00"},
+// decimal нужен клиентам, чтобы не повторять таблицу знаков валют; при разборе он игнорируется.
+// Для совместимости со старыми сообщениями принимается и число в основных
+// единицах ("amount": 1817), которое пересчитывается в минимальные единицы
+// после того, как станет известна валюта заказа (см. Order.UnmarshalJSON).
+type Money struct {
+	Amount   int64
+	Currency string
+
+	// major — исходное число из старого формата JSON до пересчета.
+	major string
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney разбирает сумму в основных единицах ("18.17") в Money.
+func ParseMoney(s, currency string) (Money, error) {
+	exp := CurrencyExponent(currency)
+	s = strings.TrimSpace(s)
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(frac) > exp {
+		return Money{}, fmt.Errorf("invalid %s amount %q", currency, s)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid %s amount %q: %w", currency, s, err)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add складывает суммы одной валюты. Нулевая сумма без валюты совместима с любой.
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := commonCurrency(m, other)
+	if err != nil {
+		return Money{}, err
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, fmt.Errorf("money overflow: %s + %s", m, other)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+// Sub вычитает other из m.
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Equal сравнивает суммы с учетом валюты.
+func (m Money) Equal(other Money) bool {
+	_, err := commonCurrency(m, other)
+	return err == nil && m.Amount == other.Amount
+}
+
+// Sum складывает суммы одной валюты.
+func Sum(values ...Money) (Money, error) {
+	var total Money
+	for _, v := range values {
+		var err error
+		if total, err = total.Add(v); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func commonCurrency(a, b Money) (string, error) {
+	switch {
+	case a.Currency == b.Currency:
+		return a.Currency, nil
+	case a.Currency == "" && a.Amount == 0:
+		return b.Currency, nil
+	case b.Currency == "" && b.Amount == 0:
+		return a.Currency, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+}
+
+// Decimal возвращает сумму в основных единицах с числом знаков валюты: "1817.00".
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absInt64(amount), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func absInt64(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+	return uint64(v)
+}
+
+// String возвращает сумму с кодом валюты: "1817.00 USD".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Decimal  string `json:"decimal,omitempty"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m.major != "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnresolvedMoney, m.major)
+	}
+	return json.Marshal(moneyJSON{Amount: m.Amount, Currency: m.Currency, Decimal: m.Decimal()})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '{' {
+		var v moneyJSON
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*m = Money{Amount: v.Amount, Currency: v.Currency}
+		return nil
+	}
+
+	// Старый формат: число в основных единицах без валюты.
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("money must be a number or {\"amount\", \"currency\"}: %w", err)
+	}
+	*m = Money{major: n.String()}
+	return nil
+}
+
+// resolve подставляет валюту заказа и пересчитывает суммы старого формата
+// в минимальные единицы.
+func (m *Money) resolve(currency string) error {
+	if m.major != "" {
+		parsed, err := ParseMoney(m.major, currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+	if m.Currency == "" {
+		m.Currency = currency
+	}
+	return nil
+}
+
+// Value сохраняет в базу только сумму в минимальных единицах.
+func (m Money) Value() (driver.Value, error) {
+	if m.major != "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnresolvedMoney, m.major)
+	}
+	return m.Amount, nil
+}
+
+// Scan читает сумму из базы; валюту заполняют хуки AfterFind моделей.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+	case int64:
+		*m = Money{Amount: v}
+	case []byte:
+		amount, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("scan money: %w", err)
+		}
+		*m = Money{Amount: amount}
+	case string:
+		amount, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("scan money: %w", err)
+		}
+		*m = Money{Amount: amount}
+	default:
+		return fmt.Errorf("scan money: unsupported type %T", src)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMoneyDecimal(t *testing.T) {
+	cases := []struct {
+		money Money
+		want  string
+	}{
+		{NewMoney(181700, "USD"), "1817.00"},
+		{NewMoney(5, "RUB"), "0.05"},
+		{NewMoney(-150, "EUR"), "-1.50"},
+		{NewMoney(1817, "JPY"), "1817"},
+		{NewMoney(1500, "KWD"), "1.500"},
+	}
+	for _, tc := range cases {
+		if got := tc.money.Decimal(); got != tc.want {
+			t.Errorf("Expected %s, got %s", tc.want, got)
+		}
+	}
+	if got := NewMoney(181700, "USD").String(); got != "1817.00 USD" {
+		t.Errorf("Expected 1817.00 USD, got %s", got)
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		in       string
+		currency string
+		want     int64
+	}{
+		{"1817", "USD", 181700},
+		{"18.17", "USD", 1817},
+		{"18.1", "USD", 1810},
+		{"1817", "JPY", 1817},
+		{"-0.5", "RUB", -50},
+	}
+	for _, tc := range cases {
+		got, err := ParseMoney(tc.in, tc.currency)
+		if err != nil {
+			t.Fatalf("ParseMoney(%q) failed: %v", tc.in, err)
+		}
+		if got != NewMoney(tc.want, tc.currency) {
+			t.Errorf("ParseMoney(%q, %s): expected %d, got %d", tc.in, tc.currency, tc.want, got.Amount)
+		}
+	}
+
+	for _, in := range []string{"18.171", "abc", ".5"} {
+		if _, err := ParseMoney(in, "USD"); err == nil {
+			t.Errorf("Expected error for %q", in)
+		}
+	}
+	if _, err := ParseMoney("1.5", "JPY"); err == nil {
+		t.Error("Expected error for fractional JPY")
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := Sum(NewMoney(100, "USD"), NewMoney(250, "USD"), Money{})
+	if err != nil {
+		t.Fatalf("Sum failed: %v", err)
+	}
+	if sum != NewMoney(350, "USD") {
+		t.Errorf("Expected 350 USD, got %s", sum)
+	}
+
+	diff, err := sum.Sub(NewMoney(50, "USD"))
+	if err != nil || diff.Amount != 300 {
+		t.Errorf("Expected 300, got %v (%v)", diff, err)
+	}
+
+	if _, err := NewMoney(1, "USD").Add(NewMoney(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+	if NewMoney(1, "USD").Equal(NewMoney(1, "EUR")) {
+		t.Error("Different currencies must not be equal")
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(181700, "USD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"amount":181700,"currency":"USD","decimal":"1817.00"}` {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	data, err = json.Marshal(NewMoney(1817, "IQD"))
+	if err != nil || string(data) != `{"amount":1817,"currency":"IQD","decimal":"1.817"}` {
+		t.Errorf("Unexpected IQD JSON: %s (%v)", data, err)
+	}
+
+	var m Money
+	if err := json.Unmarshal([]byte(`{"amount":181700,"currency":"USD","decimal":"1.00"}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m != NewMoney(181700, "USD") {
+		t.Errorf("Unexpected money: %+v", m)
+	}
+}
+
+func TestOrderUnmarshalLegacyMoney(t *testing.T) {
+	data := `{"order_uid":"uid","payment":{"currency":"USD","amount":1817,"delivery_cost":1500,"goods_total":317,"custom_fee":0},
+		"items":[{"price":453,"total_price":317}]}`
+
+	var order Order
+	if err := json.Unmarshal([]byte(data), &order); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if order.Payment.Amount != NewMoney(181700, "USD") {
+		t.Errorf("Expected 181700 USD, got %+v", order.Payment.Amount)
+	}
+	if order.Items[0].Price != NewMoney(45300, "USD") {
+		t.Errorf("Expected item price 45300 USD, got %+v", order.Items[0].Price)
+	}
+
+	// Новый формат не пересчитывается
+	data = `{"order_uid":"uid","payment":{"currency":"JPY","amount":{"amount":1817,"currency":"JPY"}}}`
+	order = Order{}
+	if err := json.Unmarshal([]byte(data), &order); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if order.Payment.Amount != NewMoney(1817, "JPY") {
+		t.Errorf("Expected 1817 JPY, got %+v", order.Payment.Amount)
+	}
+}
+
+func TestLegacyMoneyOutsideOrder(t *testing.T) {
+	var p Payment
+	if err := json.Unmarshal([]byte(`{"currency":"USD","amount":18.17}`), &p); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if p.Amount != NewMoney(1817, "USD") {
+		t.Errorf("Expected payment resolved to 1817 USD, got %+v", p.Amount)
+	}
+
+	// У товара нет валюты, поэтому сумма не может быть пересчитана и не
+	// должна молча превратиться в ноль
+	var item Items
+	if err := json.Unmarshal([]byte(`{"price":453}`), &item); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if _, err := json.Marshal(item); !errors.Is(err, ErrUnresolvedMoney) {
+		t.Errorf("Expected ErrUnresolvedMoney on marshal, got %v", err)
+	}
+	if _, err := item.Price.Value(); !errors.Is(err, ErrUnresolvedMoney) {
+		t.Errorf("Expected ErrUnresolvedMoney on save, got %v", err)
+	}
+}
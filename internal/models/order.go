@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -21,3 +23,24 @@ type Order struct {
 	Payment  Payment  `gorm:"foreignKey:OrderUID" json:"payment"`
 	Items    []Items  `gorm:"foreignKey:OrderUID" json:"items"`
 }
+
+// UnmarshalJSON дополнительно проставляет валюту платежа суммам товаров,
+// пришедшим без валюты, и пересчитывает старый формат в минимальные единицы.
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type order Order
+	if err := json.Unmarshal(data, (*order)(o)); err != nil {
+		return err
+	}
+
+	// Суммы платежа пересчитывает Payment.UnmarshalJSON, у товаров своей
+	// валюты в JSON нет, поэтому они пересчитываются по валюте платежа здесь.
+	currency := o.Payment.Currency
+	for i := range o.Items {
+		for _, m := range []*Money{&o.Items[i].Price, &o.Items[i].TotalPrice} {
+			if err := m.resolve(currency); err != nil {
+				return fmt.Errorf("items[%d]: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
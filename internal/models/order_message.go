@@ -0,0 +1,86 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// OrderMessage — исходное сообщение Kafka, из которого сохранен заказ.
+// Нужно для разбора спорных случаев и повторной обработки.
+type OrderMessage struct {
+	OrderUID  string          `gorm:"primaryKey" json:"order_uid"`
+	Topic     string          `json:"topic"`
+	Partition int             `json:"partition"`
+	Offset    int64           `gorm:"column:kafka_offset" json:"offset"`
+	Key       string          `json:"key"`
+	Headers   MessageHeaders  `gorm:"type:jsonb" json:"headers"`
+	Payload   json.RawMessage `gorm:"type:jsonb" json:"payload"`
+	// RawPayload — тело сообщения байт в байт; в JSON отдается в base64.
+	// Пусто у сообщений, сохраненных до появления колонки.
+	RawPayload []byte    `json:"raw_payload,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// HeaderEncodingBase64 — значение заголовка не является UTF-8 и записано в base64.
+const HeaderEncodingBase64 = "base64"
+
+type MessageHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Encoding пустой для текстовых значений и HeaderEncodingBase64 для двоичных.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// NewMessageHeader сохраняет значение как строку, если это UTF-8,
+// иначе кодирует его в base64, чтобы не потерять байты.
+func NewMessageHeader(key string, value []byte) MessageHeader {
+	if utf8.Valid(value) {
+		return MessageHeader{Key: key, Value: string(value)}
+	}
+	return MessageHeader{Key: key, Value: base64.StdEncoding.EncodeToString(value), Encoding: HeaderEncodingBase64}
+}
+
+// Bytes возвращает исходное значение заголовка.
+func (h MessageHeader) Bytes() ([]byte, error) {
+	switch h.Encoding {
+	case "":
+		return []byte(h.Value), nil
+	case HeaderEncodingBase64:
+		return base64.StdEncoding.DecodeString(h.Value)
+	default:
+		return nil, fmt.Errorf("unknown header encoding %q", h.Encoding)
+	}
+}
+
+// MessageHeaders хранится в колонке jsonb. Порядок и повторы ключей
+// сохраняются, поэтому это список, а не map.
+type MessageHeaders []MessageHeader
+
+func (h MessageHeaders) Value() (driver.Value, error) {
+	if h == nil {
+		h = MessageHeaders{}
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (h *MessageHeaders) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	default:
+		return fmt.Errorf("scan message headers: unsupported type %T", src)
+	}
+}
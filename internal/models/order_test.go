@@ -19,7 +19,7 @@ func TestOrderStruct(t *testing.T) {
 		DateCreated:       time.Now(),
 		OofShard:          "shard",
 		Delivery:          Delivery{Name: "Ivan"},
-		Payment:           Payment{Amount: 100},
+		Payment:           Payment{Amount: NewMoney(100, "RUB")},
 		Items:             []Items{{Name: "item1"}},
 	}
 	if order.OrderUID != "uid" {
@@ -28,7 +28,7 @@ func TestOrderStruct(t *testing.T) {
 	if order.Delivery.Name != "Ivan" {
 		t.Error("Delivery not set")
 	}
-	if order.Payment.Amount != 100 {
+	if order.Payment.Amount.Amount != 100 {
 		t.Error("Payment not set")
 	}
 	if len(order.Items) != 1 || order.Items[0].Name != "item1" {
@@ -1,16 +1,89 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type Payment struct {
-	ID           uint   `gorm:"primaryKey;autoIncrement:true" json:"-"`
-	OrderUID     string `gorm:"not null" json:"-"`
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDt    int    `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+	ID           uint      `gorm:"primaryKey;autoIncrement:true" json:"-"`
+	OrderUID     string    `gorm:"not null" json:"-"`
+	Transaction  string    `json:"transaction"`
+	RequestID    string    `json:"request_id"`
+	Currency     string    `json:"currency"`
+	Provider     string    `json:"provider"`
+	Amount       Money     `json:"amount"`
+	PaymentDt    time.Time `json:"payment_dt"`
+	Bank         string    `json:"bank"`
+	DeliveryCost Money     `json:"delivery_cost"`
+	GoodsTotal   Money     `json:"goods_total"`
+	CustomFee    Money     `json:"custom_fee"`
+}
+
+func (p *Payment) money() []*Money {
+	return []*Money{&p.Amount, &p.DeliveryCost, &p.GoodsTotal, &p.CustomFee}
+}
+
+// AfterFind восстанавливает валюту сумм из колонки currency
+// и приводит время оплаты к UTC.
+func (p *Payment) AfterFind(*gorm.DB) error {
+	for _, m := range p.money() {
+		m.Currency = p.Currency
+	}
+	p.PaymentDt = p.PaymentDt.UTC()
+	return nil
+}
+
+// UnmarshalJSON принимает payment_dt как Unix-время в секундах (старый формат)
+// или строку RFC 3339 и пересчитывает суммы старого формата по валюте платежа.
+func (p *Payment) UnmarshalJSON(data []byte) error {
+	type payment Payment
+	aux := struct {
+		*payment
+		PaymentDt json.RawMessage `json:"payment_dt"`
+	}{payment: (*payment)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	dt, err := parsePaymentDt(aux.PaymentDt)
+	if err != nil {
+		return fmt.Errorf("payment_dt: %w", err)
+	}
+	p.PaymentDt = dt
+
+	for _, m := range p.money() {
+		if err := m.resolve(p.Currency); err != nil {
+			return fmt.Errorf("payment: %w", err)
+		}
+	}
+	return nil
+}
+
+func parsePaymentDt(raw json.RawMessage) (time.Time, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return time.Time{}, nil
+	}
+
+	var s string
+	if raw[0] == '"' {
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return time.Time{}, err
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t.UTC(), nil
+		}
+	} else {
+		s = string(raw)
+	}
+
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected Unix seconds or RFC 3339, got %s", raw)
+	}
+	return time.Unix(sec, 0).UTC(), nil
 }
@@ -1,6 +1,11 @@
 package models
 
-import "testing"
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestPaymentStruct(t *testing.T) {
 	p := Payment{
@@ -8,14 +13,14 @@ func TestPaymentStruct(t *testing.T) {
 		RequestID:    "req",
 		Currency:     "RUB",
 		Provider:     "bank",
-		Amount:       1000,
-		PaymentDt:    123456,
+		Amount:       NewMoney(1000, "RUB"),
+		PaymentDt:    time.Unix(123456, 0),
 		Bank:         "Sber",
-		DeliveryCost: 100,
-		GoodsTotal:   900,
-		CustomFee:    10,
+		DeliveryCost: NewMoney(100, "RUB"),
+		GoodsTotal:   NewMoney(900, "RUB"),
+		CustomFee:    NewMoney(10, "RUB"),
 	}
-	if p.Amount != 1000 {
+	if p.Amount.Amount != 1000 {
 		t.Error("Amount not set")
 	}
 	if p.Currency != "RUB" {
@@ -25,3 +30,36 @@ func TestPaymentStruct(t *testing.T) {
 		t.Error("Bank not set")
 	}
 }
+
+func TestPaymentUnmarshalPaymentDt(t *testing.T) {
+	want := time.Date(2021, 11, 26, 6, 22, 7, 0, time.UTC)
+	cases := map[string]string{
+		"unix seconds":  `{"payment_dt":1637907727}`,
+		"numeric str":   `{"payment_dt":"1637907727"}`,
+		"rfc3339":       `{"payment_dt":"2021-11-26T06:22:07Z"}`,
+		"rfc3339 local": `{"payment_dt":"2021-11-26T09:22:07+03:00"}`,
+	}
+	for name, data := range cases {
+		var p Payment
+		if err := json.Unmarshal([]byte(data), &p); err != nil {
+			t.Errorf("%s: unmarshal failed: %v", name, err)
+			continue
+		}
+		if !p.PaymentDt.Equal(want) || p.PaymentDt.Location() != time.UTC {
+			t.Errorf("%s: expected %v, got %v", name, want, p.PaymentDt)
+		}
+	}
+
+	var p Payment
+	if err := json.Unmarshal([]byte(`{"payment_dt":"yesterday"}`), &p); err == nil {
+		t.Error("Expected error for invalid payment_dt")
+	}
+
+	data, err := json.Marshal(Payment{PaymentDt: want})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"payment_dt":"2021-11-26T06:22:07Z"`) {
+		t.Errorf("Expected RFC 3339 payment_dt, got %s", data)
+	}
+}
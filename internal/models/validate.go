@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrValidation = errors.New("order validation failed")
+
+// Validate проверяет обязательные поля заказа и сходимость сумм:
+// goods_total равен сумме total_price товаров, а amount — goods_total
+// плюс доставка и пошлина. Все суммы должны быть в валюте платежа.
+func (o *Order) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if o.OrderUID == "" {
+		add("order_uid is empty")
+	}
+
+	p := o.Payment
+	if !ValidCurrency(p.Currency) {
+		add("payment.currency %q is not an ISO 4217 code", p.Currency)
+	}
+	type field struct {
+		name  string
+		value Money
+	}
+	fields := []field{
+		{"payment.amount", p.Amount},
+		{"payment.delivery_cost", p.DeliveryCost},
+		{"payment.goods_total", p.GoodsTotal},
+		{"payment.custom_fee", p.CustomFee},
+	}
+	for i, item := range o.Items {
+		fields = append(fields,
+			field{fmt.Sprintf("items[%d].price", i), item.Price},
+			field{fmt.Sprintf("items[%d].total_price", i), item.TotalPrice},
+		)
+	}
+	for _, f := range fields {
+		if f.value.IsNegative() {
+			add("%s is negative", f.name)
+		}
+		if f.value.Currency != "" && f.value.Currency != p.Currency {
+			add("%s currency %s differs from payment currency %s", f.name, f.value.Currency, p.Currency)
+		}
+	}
+	if len(problems) > 0 {
+		return validationError(problems)
+	}
+
+	itemsTotal := Money{Currency: p.Currency}
+	for _, item := range o.Items {
+		var err error
+		if itemsTotal, err = itemsTotal.Add(item.TotalPrice); err != nil {
+			return validationError([]string{err.Error()})
+		}
+	}
+	if len(o.Items) > 0 && !itemsTotal.Equal(p.GoodsTotal) {
+		add("payment.goods_total %s does not match items total %s", p.GoodsTotal, itemsTotal)
+	}
+
+	expected, err := Sum(p.GoodsTotal, p.DeliveryCost, p.CustomFee)
+	if err != nil {
+		return validationError([]string{err.Error()})
+	}
+	if !expected.Equal(p.Amount) {
+		add("payment.amount %s does not match goods_total + delivery_cost + custom_fee = %s", p.Amount, expected)
+	}
+
+	if len(problems) > 0 {
+		return validationError(problems)
+	}
+	return nil
+}
+
+func validationError(problems []string) error {
+	return fmt.Errorf("%w: %s", ErrValidation, strings.Join(problems, "; "))
+}
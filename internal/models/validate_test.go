@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func validOrder() Order {
+	return Order{
+		OrderUID: "uid",
+		Payment: Payment{
+			Currency:     "USD",
+			Amount:       NewMoney(181700, "USD"),
+			DeliveryCost: NewMoney(150000, "USD"),
+			GoodsTotal:   NewMoney(31700, "USD"),
+		},
+		Items: []Items{{Price: NewMoney(45300, "USD"), TotalPrice: NewMoney(31700, "USD")}},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	order := validOrder()
+	if err := order.Validate(); err != nil {
+		t.Errorf("Expected valid order, got %v", err)
+	}
+
+	cases := map[string]func(o *Order){
+		"empty order_uid":   func(o *Order) { o.OrderUID = "" },
+		"invalid currency":  func(o *Order) { o.Payment.Currency = "usd" },
+		"negative fee":      func(o *Order) { o.Payment.CustomFee = NewMoney(-1, "USD") },
+		"goods total":       func(o *Order) { o.Payment.GoodsTotal = NewMoney(1, "USD") },
+		"amount":            func(o *Order) { o.Payment.Amount = NewMoney(1, "USD") },
+		"item currency":     func(o *Order) { o.Items[0].TotalPrice = NewMoney(31700, "EUR") },
+		"delivery currency": func(o *Order) { o.Payment.DeliveryCost = NewMoney(150000, "RUB") },
+	}
+	for name, mutate := range cases {
+		order := validOrder()
+		mutate(&order)
+		if err := order.Validate(); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: expected ErrValidation, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package orders содержит чтение заказов, общее для HTTP и gRPC API.
+package orders
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+)
+
+// MaxBatchSize ограничивает число order_uid в одном пакетном запросе.
+const MaxBatchSize = 1000
+
+// Batch — результат пакетного запроса: найденные заказы в порядке запроса
+// и ненайденные order_uid.
+type Batch struct {
+	Orders  []*models.Order
+	Missing []string
+	// CacheMisses — сколько заказов пришлось искать в базе.
+	CacheMisses int
+}
+
+// BatchIDs проверяет список order_uid и убирает пустые значения и повторы
+// с сохранением порядка.
+func BatchIDs(uids []string) ([]string, error) {
+	if len(uids) > MaxBatchSize {
+		return nil, fmt.Errorf("order_uids must contain at most %d values", MaxBatchSize)
+	}
+	seen := make(map[string]struct{}, len(uids))
+	result := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		if _, dup := seen[uid]; dup || uid == "" {
+			continue
+		}
+		seen[uid] = struct{}{}
+		result = append(result, uid)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("order_uids is required")
+	}
+	return result, nil
+}
+
+// BatchGet ищет заказы по списку из BatchIDs: найденные в кэше отдаются
+// сразу, остальные загружаются одним запросом к базе и кладутся в кэш.
+func BatchGet(ctx context.Context, c *cache.Cache, repo repository.OrderRepository, uids []string) (Batch, error) {
+	found := make(map[string]*models.Order, len(uids))
+	var misses []string
+	for _, uid := range uids {
+		if cached, ok := c.Get(uid); ok {
+			if order, ok := cached.(*models.Order); ok {
+				found[uid] = order
+				continue
+			}
+		}
+		misses = append(misses, uid)
+	}
+
+	if len(misses) > 0 {
+		stored, err := repo.GetMany(ctx, misses)
+		if err != nil {
+			return Batch{}, fmt.Errorf("get %d orders: %w", len(misses), err)
+		}
+		for i := range stored {
+			order := &stored[i]
+			found[order.OrderUID] = order
+			c.Set(order.OrderUID, order)
+		}
+	}
+
+	batch := Batch{Orders: make([]*models.Order, 0, len(found)), Missing: []string{}, CacheMisses: len(misses)}
+	for _, uid := range uids {
+		if order, ok := found[uid]; ok {
+			batch.Orders = append(batch.Orders, order)
+		} else {
+			batch.Missing = append(batch.Missing, uid)
+		}
+	}
+	return batch, nil
+}
@@ -0,0 +1,51 @@
+package orders
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gegxkss/wbL0/internal/cache"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+)
+
+func TestBatchIDs(t *testing.T) {
+	uids, err := BatchIDs([]string{"b", "", "a", "b"})
+	if err != nil || strings.Join(uids, ",") != "b,a" {
+		t.Errorf("Expected b,a, got %v (%v)", uids, err)
+	}
+	if _, err := BatchIDs([]string{"", ""}); err == nil {
+		t.Error("Expected error for empty list")
+	}
+	if _, err := BatchIDs(make([]string, MaxBatchSize+1)); err == nil {
+		t.Error("Expected error for too many values")
+	}
+}
+
+func TestBatchGet(t *testing.T) {
+	repo := repository.NewMemory()
+	c := cache.NewCache(logger.Nop())
+	if err := repo.Save(context.Background(), &models.Order{OrderUID: "db"}); err != nil {
+		t.Fatal(err)
+	}
+	c.Set("cached", &models.Order{OrderUID: "cached"})
+
+	batch, err := BatchGet(context.Background(), c, repo, []string{"db", "missing", "cached"})
+	if err != nil {
+		t.Fatalf("BatchGet failed: %v", err)
+	}
+	if len(batch.Orders) != 2 || batch.Orders[0].OrderUID != "db" || batch.Orders[1].OrderUID != "cached" {
+		t.Errorf("Expected db and cached in request order, got %+v", batch.Orders)
+	}
+	if len(batch.Missing) != 1 || batch.Missing[0] != "missing" {
+		t.Errorf("Expected missing [missing], got %v", batch.Missing)
+	}
+	if batch.CacheMisses != 2 {
+		t.Errorf("Expected 2 cache misses, got %d", batch.CacheMisses)
+	}
+	if _, ok := c.Get("db"); !ok {
+		t.Error("Order loaded from repository was not cached")
+	}
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gegxkss/wbL0/internal/models"
+)
+
+// Memory хранит заказы в памяти. Используется в тестах и при локальной отладке.
+type Memory struct {
+	mu       sync.RWMutex
+	orders   map[string]models.Order
+	messages map[string]models.OrderMessage
+}
+
+var _ OrderRepository = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{
+		orders:   make(map[string]models.Order),
+		messages: make(map[string]models.OrderMessage),
+	}
+}
+
+func (r *Memory) Get(_ context.Context, orderUID string) (*models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order, ok := r.orders[orderUID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	order = clone(order)
+	return &order, nil
+}
+
+func (r *Memory) GetMany(_ context.Context, orderUIDs []string) ([]models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var orders []models.Order
+	for _, uid := range orderUIDs {
+		if order, ok := r.orders[uid]; ok {
+			orders = append(orders, clone(order))
+		}
+	}
+	return orders, nil
+}
+
+func (r *Memory) GetByTrackNumber(ctx context.Context, trackNumber string) (*models.Order, error) {
+	return r.getNewest(ctx, func(o models.Order) bool { return o.TrackNumber == trackNumber })
+}
+
+func (r *Memory) GetByTransaction(ctx context.Context, transaction string) (*models.Order, error) {
+	return r.getNewest(ctx, func(o models.Order) bool { return o.Payment.Transaction == transaction })
+}
+
+func (r *Memory) getNewest(ctx context.Context, match func(models.Order) bool) (*models.Order, error) {
+	orders, _ := r.List(ctx, -1)
+	for _, order := range orders {
+		if match(order) {
+			return &order, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *Memory) Save(ctx context.Context, order *models.Order) error {
+	return r.SaveWithMessage(ctx, order, nil)
+}
+
+func (r *Memory) SaveWithMessage(_ context.Context, order *models.Order, msg *models.OrderMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.orders[order.OrderUID]; exists {
+		return fmt.Errorf("create order failed: %w: order_uid=%s", ErrAlreadyExists, order.OrderUID)
+	}
+	r.orders[order.OrderUID] = clone(*order)
+	if msg != nil {
+		m := cloneMessage(*msg)
+		m.OrderUID = order.OrderUID
+		r.messages[order.OrderUID] = m
+	}
+	return nil
+}
+
+func (r *Memory) GetMessage(_ context.Context, orderUID string) (*models.OrderMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	msg, ok := r.messages[orderUID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	msg = cloneMessage(msg)
+	return &msg, nil
+}
+
+func (r *Memory) List(_ context.Context, limit int) ([]models.Order, error) {
+	r.mu.RLock()
+	orders := make([]models.Order, 0, len(r.orders))
+	for _, order := range r.orders {
+		orders = append(orders, clone(order))
+	}
+	r.mu.RUnlock()
+
+	// Порядок совпадает с Postgres: date_created DESC, order_uid DESC
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].DateCreated.Equal(orders[j].DateCreated) {
+			return orders[i].OrderUID > orders[j].OrderUID
+		}
+		return orders[i].DateCreated.After(orders[j].DateCreated)
+	})
+	if limit >= 0 && len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+func (r *Memory) Find(ctx context.Context, q OrderQuery) (OrderPage, error) {
+	after, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return OrderPage{}, err
+	}
+
+	all, _ := r.List(ctx, -1)
+
+	var matched []models.Order
+	var total int64
+	limit := q.limit()
+	for _, order := range all {
+		if !q.Filter.match(order) {
+			continue
+		}
+		total++
+		if after.after(order) && len(matched) <= limit {
+			matched = append(matched, order)
+		}
+	}
+
+	p := page(matched, limit)
+	if q.WithTotal {
+		p.Total = &total
+	}
+	return p, nil
+}
+
+func (r *Memory) CustomerSummary(ctx context.Context, customerID string) (CustomerSummary, error) {
+	var summary CustomerSummary
+	totals := make(map[string]int64)
+	orders, _ := r.List(ctx, -1)
+	for _, order := range orders {
+		if order.CustomerId != customerID {
+			continue
+		}
+		summary.OrderCount++
+		if summary.FirstOrderAt.IsZero() || order.DateCreated.Before(summary.FirstOrderAt) {
+			summary.FirstOrderAt = order.DateCreated
+		}
+		if order.DateCreated.After(summary.LastOrderAt) {
+			summary.LastOrderAt = order.DateCreated
+		}
+		totals[order.Payment.Currency] += order.Payment.Amount.Amount
+	}
+
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		summary.TotalSpent = append(summary.TotalSpent, models.NewMoney(totals[currency], currency))
+	}
+	return summary, nil
+}
+
+func (r *Memory) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	orders, _ := r.List(ctx, -1)
+	byUID := make(map[string]models.Order, len(orders))
+	var rows []searchRow
+	for _, order := range orders {
+		byUID[order.OrderUID] = order
+		fields := []SearchMatch{
+			{Field: FieldDeliveryName, Value: order.Delivery.Name},
+			{Field: FieldDeliveryCity, Value: order.Delivery.City},
+			{Field: FieldDeliveryPhone, Value: order.Delivery.Phone},
+		}
+		for _, item := range order.Items {
+			fields = append(fields,
+				SearchMatch{Field: FieldItemName, Value: item.Name},
+				SearchMatch{Field: FieldItemBrand, Value: item.Brand},
+			)
+		}
+		for _, f := range fields {
+			if score, ok := substringScore(query, f.Value); ok {
+				f.Score = score
+				rows = append(rows, searchRow{OrderUID: order.OrderUID, SearchMatch: f})
+			}
+		}
+	}
+
+	uids, matches := groupMatches(rows, limit)
+	return searchResults(uids, byUID, matches), nil
+}
+
+func (r *Memory) Delete(_ context.Context, orderUID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.orders[orderUID]; !ok {
+		return ErrNotFound
+	}
+	delete(r.orders, orderUID)
+	delete(r.messages, orderUID)
+	return nil
+}
+
+func clone(order models.Order) models.Order {
+	order.Items = append([]models.Items(nil), order.Items...)
+	order.Delivery.OrderUID = order.OrderUID
+	order.Payment.OrderUID = order.OrderUID
+	for i := range order.Items {
+		order.Items[i].OrderUID = order.OrderUID
+	}
+	return order
+}
+
+func cloneMessage(msg models.OrderMessage) models.OrderMessage {
+	msg.Headers = append(models.MessageHeaders(nil), msg.Headers...)
+	msg.Payload = append([]byte(nil), msg.Payload...)
+	msg.RawPayload = append([]byte(nil), msg.RawPayload...)
+	return msg
+}
@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type Postgres struct {
+	db *gorm.DB
+}
+
+var _ OrderRepository = (*Postgres)(nil)
+
+func NewPostgres(db *gorm.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (r *Postgres) withOrderRelations(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Preload("Delivery").Preload("Payment").Preload("Items")
+}
+
+func (r *Postgres) Get(ctx context.Context, orderUID string) (*models.Order, error) {
+	var order models.Order
+	err := r.withOrderRelations(ctx).Where("order_uid = ?", orderUID).First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+	return &order, nil
+}
+
+func (r *Postgres) GetMany(ctx context.Context, orderUIDs []string) ([]models.Order, error) {
+	if len(orderUIDs) == 0 {
+		return nil, nil
+	}
+	var orders []models.Order
+	if err := r.withOrderRelations(ctx).Where("order_uid IN ?", orderUIDs).Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("get orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *Postgres) GetByTrackNumber(ctx context.Context, trackNumber string) (*models.Order, error) {
+	return r.getWhere(r.withOrderRelations(ctx).Where("orders.track_number = ?", trackNumber))
+}
+
+func (r *Postgres) GetByTransaction(ctx context.Context, transaction string) (*models.Order, error) {
+	return r.getWhere(r.withOrderRelations(ctx).
+		Where("EXISTS (SELECT 1 FROM payments WHERE payments.order_uid = orders.order_uid AND payments.transaction = ?)", transaction))
+}
+
+func (r *Postgres) getWhere(query *gorm.DB) (*models.Order, error) {
+	var order models.Order
+	err := query.Order("orders.date_created DESC, orders.order_uid DESC").First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+	return &order, nil
+}
+
+func (r *Postgres) Save(ctx context.Context, order *models.Order) error {
+	return r.SaveWithMessage(ctx, order, nil)
+}
+
+func (r *Postgres) SaveWithMessage(ctx context.Context, order *models.Order, msg *models.OrderMessage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Сохраняем заказ
+		orderToSave := *order
+		orderToSave.Delivery = models.Delivery{}
+		orderToSave.Payment = models.Payment{}
+		orderToSave.Items = nil
+		if err := tx.Create(&orderToSave).Error; err != nil {
+			return saveError("order", err)
+		}
+
+		// Сохраняем доставку
+		delivery := order.Delivery
+		delivery.ID = 0
+		delivery.OrderUID = order.OrderUID
+		if err := tx.Create(&delivery).Error; err != nil {
+			return saveError("delivery", err)
+		}
+
+		// Сохраняем товары
+		for i := range order.Items {
+			item := order.Items[i]
+			item.ID = 0
+			item.OrderUID = order.OrderUID
+			if err := tx.Create(&item).Error; err != nil {
+				return saveError("item", err)
+			}
+		}
+
+		// Сохраняем оплату
+		payment := order.Payment
+		payment.ID = 0
+		payment.OrderUID = order.OrderUID
+		if err := tx.Create(&payment).Error; err != nil {
+			return saveError("payment", err)
+		}
+
+		// Сохраняем исходное сообщение
+		if msg != nil {
+			source := *msg
+			source.OrderUID = order.OrderUID
+			if err := tx.Create(&source).Error; err != nil {
+				return saveError("order message", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *Postgres) GetMessage(ctx context.Context, orderUID string) (*models.OrderMessage, error) {
+	var msg models.OrderMessage
+	err := r.db.WithContext(ctx).Where("order_uid = ?", orderUID).First(&msg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get order message: %w", err)
+	}
+	return &msg, nil
+}
+
+func (r *Postgres) List(ctx context.Context, limit int) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.withOrderRelations(ctx).Order("date_created DESC, order_uid DESC").Limit(limit).Find(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("list orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *Postgres) Find(ctx context.Context, q OrderQuery) (OrderPage, error) {
+	after, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return OrderPage{}, err
+	}
+	limit := q.limit()
+
+	var total *int64
+	if q.WithTotal {
+		total = new(int64)
+		if err := applyFilter(r.db.WithContext(ctx).Model(&models.Order{}), q.Filter).Count(total).Error; err != nil {
+			return OrderPage{}, fmt.Errorf("count orders: %w", err)
+		}
+	}
+
+	query := applyFilter(r.withOrderRelations(ctx), q.Filter)
+	if after != nil {
+		query = query.Where("(orders.date_created, orders.order_uid) < (?, ?)", after.DateCreated, after.OrderUID)
+	}
+	var orders []models.Order
+	err = query.Order("orders.date_created DESC, orders.order_uid DESC").Limit(limit + 1).Find(&orders).Error
+	if err != nil {
+		return OrderPage{}, fmt.Errorf("find orders: %w", err)
+	}
+
+	p := page(orders, limit)
+	p.Total = total
+	return p, nil
+}
+
+func applyFilter(db *gorm.DB, f OrderFilter) *gorm.DB {
+	if f.CustomerID != "" {
+		db = db.Where("orders.customer_id = ?", f.CustomerID)
+	}
+	if f.TrackNumber != "" {
+		db = db.Where("orders.track_number = ?", f.TrackNumber)
+	}
+	if f.DeliveryService != "" {
+		db = db.Where("orders.delivery_service = ?", f.DeliveryService)
+	}
+	if f.Locale != "" {
+		db = db.Where("orders.locale = ?", f.Locale)
+	}
+	if f.Brand != "" {
+		db = db.Where("EXISTS (SELECT 1 FROM items WHERE items.order_uid = orders.order_uid AND items.brand = ?)", f.Brand)
+	}
+	if !f.CreatedFrom.IsZero() {
+		db = db.Where("orders.date_created >= ?", f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		db = db.Where("orders.date_created < ?", f.CreatedTo)
+	}
+	return db
+}
+
+func (r *Postgres) CustomerSummary(ctx context.Context, customerID string) (CustomerSummary, error) {
+	var summary CustomerSummary
+	var dates struct {
+		Count int64
+		First *time.Time
+		Last  *time.Time
+	}
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Select("COUNT(*) AS count, MIN(date_created) AS first, MAX(date_created) AS last").
+		Where("customer_id = ?", customerID).
+		Scan(&dates).Error
+	if err != nil {
+		return summary, fmt.Errorf("customer summary: %w", err)
+	}
+	summary.OrderCount = dates.Count
+	if dates.First != nil {
+		summary.FirstOrderAt = *dates.First
+		summary.LastOrderAt = *dates.Last
+	}
+
+	var totals []struct {
+		Currency string
+		Total    int64
+	}
+	err = r.db.WithContext(ctx).Table("payments").
+		Select("payments.currency, SUM(payments.amount) AS total").
+		Joins("JOIN orders ON orders.order_uid = payments.order_uid").
+		Where("orders.customer_id = ?", customerID).
+		Group("payments.currency").
+		Order("payments.currency").
+		Scan(&totals).Error
+	if err != nil {
+		return summary, fmt.Errorf("customer totals: %w", err)
+	}
+	for _, t := range totals {
+		summary.TotalSpent = append(summary.TotalSpent, models.NewMoney(t.Total, t.Currency))
+	}
+	return summary, nil
+}
+
+// searchQuery ищет совпадения по каждому полю отдельно. Точное вхождение
+// подстроки (ILIKE) ставится выше нечеткого совпадения по триграммам (<%).
+// Оба условия используют GIN индексы pg_trgm из миграции 0008. Заказы
+// ранжируются по лучшему совпадению до LIMIT, поэтому заказ с множеством
+// совпавших товаров не вытесняет остальные; для отобранных заказов
+// возвращаются все совпадения.
+const searchQuery = `
+WITH m AS (
+    SELECT order_uid, 'items.name' AS field, name AS value,
+           word_similarity(@q, name) + CASE WHEN name ILIKE @like THEN 1 ELSE 0 END AS score
+    FROM items WHERE name ILIKE @like OR @q <% name
+    UNION ALL
+    SELECT order_uid, 'items.brand', brand,
+           word_similarity(@q, brand) + CASE WHEN brand ILIKE @like THEN 1 ELSE 0 END
+    FROM items WHERE brand ILIKE @like OR @q <% brand
+    UNION ALL
+    SELECT order_uid, 'delivery.name', name,
+           word_similarity(@q, name) + CASE WHEN name ILIKE @like THEN 1 ELSE 0 END
+    FROM deliveries WHERE name ILIKE @like OR @q <% name
+    UNION ALL
+    SELECT order_uid, 'delivery.city', city,
+           word_similarity(@q, city) + CASE WHEN city ILIKE @like THEN 1 ELSE 0 END
+    FROM deliveries WHERE city ILIKE @like OR @q <% city
+    UNION ALL
+    SELECT order_uid, 'delivery.phone', phone,
+           word_similarity(@q, phone) + CASE WHEN phone ILIKE @like THEN 1 ELSE 0 END
+    FROM deliveries WHERE phone ILIKE @like OR @q <% phone
+), top AS (
+    SELECT order_uid, max(score) AS best
+    FROM m
+    GROUP BY order_uid
+    ORDER BY best DESC, order_uid
+    LIMIT @limit
+)
+SELECT m.order_uid, m.field, m.value, m.score
+FROM m JOIN top ON top.order_uid = m.order_uid
+ORDER BY top.best DESC, m.order_uid, m.score DESC`
+
+func (r *Postgres) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	var rows []searchRow
+	err := r.db.WithContext(ctx).Raw(searchQuery, map[string]any{
+		"q":     query,
+		"like":  "%" + escapeLike(query) + "%",
+		"limit": limit,
+	}).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("search orders: %w", err)
+	}
+
+	uids, matches := groupMatches(rows, limit)
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	var orders []models.Order
+	if err := r.withOrderRelations(ctx).Where("order_uid IN ?", uids).Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("search orders: %w", err)
+	}
+	byUID := make(map[string]models.Order, len(orders))
+	for _, order := range orders {
+		byUID[order.OrderUID] = order
+	}
+	return searchResults(uids, byUID, matches), nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Delete удаляет заказ; доставка, оплата и товары удаляются каскадно.
+func (r *Postgres) Delete(ctx context.Context, orderUID string) error {
+	res := r.db.WithContext(ctx).Where("order_uid = ?", orderUID).Delete(&models.Order{})
+	if res.Error != nil {
+		return fmt.Errorf("delete order: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Коды ошибок PostgreSQL, см. https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+	pgStringTooLong       = "22001"
+	pgNumericOutOfRange   = "22003"
+)
+
+// saveError переводит нарушения ограничений схемы в ErrAlreadyExists
+// или ErrInvalidOrder, сохраняя подробности из PostgreSQL в тексте ошибки.
+func saveError(entity string, err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return fmt.Errorf("create %s failed: %w", entity, err)
+	}
+
+	detail := pgErr.Detail
+	if detail == "" {
+		detail = pgErr.Message
+	}
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		if pgErr.TableName == "orders" {
+			return fmt.Errorf("create %s failed: %w: %s", entity, ErrAlreadyExists, detail)
+		}
+		return fmt.Errorf("create %s failed: %w: duplicate %s (%s): %s", entity, ErrInvalidOrder, entity, pgErr.ConstraintName, detail)
+	case pgForeignKeyViolation, pgNotNullViolation, pgCheckViolation:
+		return fmt.Errorf("create %s failed: %w: constraint %s: %s", entity, ErrInvalidOrder, constraintName(pgErr), detail)
+	case pgStringTooLong, pgNumericOutOfRange:
+		return fmt.Errorf("create %s failed: %w: %s", entity, ErrInvalidOrder, pgErr.Message)
+	default:
+		return fmt.Errorf("create %s failed: %w", entity, err)
+	}
+}
+
+func constraintName(pgErr *pgconn.PgError) string {
+	if pgErr.ConstraintName != "" {
+		return pgErr.ConstraintName
+	}
+	// У NOT NULL нет имени ограничения, показываем колонку.
+	return pgErr.TableName + "." + pgErr.ColumnName
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/testdb"
+	"github.com/gegxkss/wbL0/migrations"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func openPostgres(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testdb.Open(t)
+	if err := migrations.Apply(context.Background(), db, logger.Nop()); err != nil {
+		t.Fatalf("Migration failed: %v", err)
+	}
+	// Доставка, оплата и товары удаляются каскадно
+	db.Where("order_uid LIKE ?", "repo-%").Delete(&models.Order{})
+	return db
+}
+
+func TestPostgres(t *testing.T) {
+	repo := NewPostgres(openPostgres(t))
+	testRepository(t, repo)
+	testRepositoryFind(t, repo)
+}
+
+func TestPostgresConstraints(t *testing.T) {
+	db := openPostgres(t)
+	repo := NewPostgres(db)
+	ctx := context.Background()
+
+	if err := repo.Save(ctx, testOrder("repo-fk", time.Now())); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Вторая доставка для заказа нарушает уникальность
+	err := db.Create(&models.Delivery{OrderUID: "repo-fk", Name: "Second"}).Error
+	if err := saveError("delivery", err); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("Expected ErrInvalidOrder for second delivery, got %v", err)
+	}
+	// Товар без заказа нарушает внешний ключ
+	err = db.Create(&models.Items{OrderUID: "repo-missing", Name: "Orphan"}).Error
+	if err := saveError("item", err); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("Expected ErrInvalidOrder for orphan item, got %v", err)
+	}
+
+	if err := repo.Delete(ctx, "repo-fk"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	for _, table := range []string{"deliveries", "payments", "items"} {
+		var count int64
+		db.Table(table).Where("order_uid = ?", "repo-fk").Count(&count)
+		if count != 0 {
+			t.Errorf("Expected %s to be deleted by cascade, got %d rows", table, count)
+		}
+	}
+}
+
+func TestSaveError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"duplicate order", &pgconn.PgError{Code: pgUniqueViolation, TableName: "orders", ConstraintName: "orders_pkey"}, ErrAlreadyExists},
+		{"duplicate payment", &pgconn.PgError{Code: pgUniqueViolation, TableName: "payments", ConstraintName: "uq_payments_order_uid"}, ErrInvalidOrder},
+		{"missing order", &pgconn.PgError{Code: pgForeignKeyViolation, TableName: "items", ConstraintName: "fk_items_order"}, ErrInvalidOrder},
+		{"not null", &pgconn.PgError{Code: pgNotNullViolation, TableName: "items", ColumnName: "order_uid"}, ErrInvalidOrder},
+		{"too long", &pgconn.PgError{Code: pgStringTooLong, Message: "value too long"}, ErrInvalidOrder},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := saveError("order", tc.err); !errors.Is(err, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+
+	other := errors.New("connection reset")
+	err := saveError("order", other)
+	if !errors.Is(err, other) || errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("Expected wrapped original error, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/models"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// OrderFilter — условия отбора заказов. Пустые поля не учитываются.
+type OrderFilter struct {
+	CustomerID      string
+	TrackNumber     string
+	DeliveryService string
+	Locale          string
+	// Brand отбирает заказы, в которых есть хотя бы один товар этого бренда.
+	Brand string
+	// CreatedFrom и CreatedTo ограничивают date_created: [CreatedFrom, CreatedTo).
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+// Validate проверяет, что диапазон дат не пуст. Вызывается транспортом
+// до запроса, чтобы ошибка вернулась клиенту как неверный параметр.
+func (f OrderFilter) Validate() error {
+	if !f.CreatedFrom.IsZero() && !f.CreatedTo.IsZero() && !f.CreatedFrom.Before(f.CreatedTo) {
+		return fmt.Errorf("created_from must be before created_to")
+	}
+	return nil
+}
+
+// match повторяет условия applyFilter для хранилища в памяти.
+func (f OrderFilter) match(order models.Order) bool {
+	switch {
+	case f.CustomerID != "" && order.CustomerId != f.CustomerID,
+		f.TrackNumber != "" && order.TrackNumber != f.TrackNumber,
+		f.DeliveryService != "" && order.DeliveryService != f.DeliveryService,
+		f.Locale != "" && order.Locale != f.Locale,
+		!f.CreatedFrom.IsZero() && order.DateCreated.Before(f.CreatedFrom),
+		!f.CreatedTo.IsZero() && !order.DateCreated.Before(f.CreatedTo):
+		return false
+	}
+	if f.Brand == "" {
+		return true
+	}
+	for _, item := range order.Items {
+		if item.Brand == f.Brand {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderQuery — запрос страницы заказов, отсортированных от новых к старым.
+type OrderQuery struct {
+	Filter OrderFilter
+	Limit  int
+	// Cursor — NextCursor предыдущей страницы; пустой для первой.
+	Cursor string
+	// WithTotal включает подсчет всех заказов, подходящих под фильтр.
+	WithTotal bool
+}
+
+type OrderPage struct {
+	Orders []models.Order
+	// NextCursor пустой, если это последняя страница.
+	NextCursor string
+	// Total заполняется только при OrderQuery.WithTotal.
+	Total *int64
+}
+
+func (q OrderQuery) limit() int {
+	switch {
+	case q.Limit <= 0:
+		return DefaultPageSize
+	case q.Limit > MaxPageSize:
+		return MaxPageSize
+	default:
+		return q.Limit
+	}
+}
+
+// CustomerSummary — сводка по заказам клиента.
+type CustomerSummary struct {
+	OrderCount int64
+	// TotalSpent — сумма payment.amount по каждой валюте, по алфавиту валют.
+	TotalSpent   []models.Money
+	FirstOrderAt time.Time
+	LastOrderAt  time.Time
+}
+
+// cursor — позиция последнего заказа страницы в сортировке
+// (date_created DESC, order_uid DESC).
+type cursor struct {
+	DateCreated time.Time `json:"d"`
+	OrderUID    string    `json:"u"`
+}
+
+func encodeCursor(order models.Order) string {
+	data, _ := json.Marshal(cursor{DateCreated: order.DateCreated, OrderUID: order.OrderUID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.OrderUID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// after сообщает, идет ли заказ после курсора в порядке выдачи.
+func (c *cursor) after(order models.Order) bool {
+	if c == nil {
+		return true
+	}
+	if !order.DateCreated.Equal(c.DateCreated) {
+		return order.DateCreated.Before(c.DateCreated)
+	}
+	return order.OrderUID < c.OrderUID
+}
+
+// page обрезает выборку из limit+1 заказов до limit и вычисляет курсор.
+func page(orders []models.Order, limit int) OrderPage {
+	if len(orders) <= limit {
+		return OrderPage{Orders: orders}
+	}
+	orders = orders[:limit]
+	return OrderPage{Orders: orders, NextCursor: encodeCursor(orders[limit-1])}
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gegxkss/wbL0/internal/models"
+)
+
+var (
+	ErrNotFound = errors.New("order not found")
+	// ErrAlreadyExists — заказ с таким order_uid уже сохранен.
+	ErrAlreadyExists = errors.New("order already exists")
+	// ErrInvalidOrder — данные заказа нарушают ограничения схемы
+	// (пустое обязательное поле, ссылка на несуществующий заказ и т.п.).
+	ErrInvalidOrder = errors.New("invalid order")
+)
+
+// OrderRepository хранит заказы вместе с доставкой, оплатой и товарами.
+type OrderRepository interface {
+	// Get возвращает заказ или ErrNotFound.
+	Get(ctx context.Context, orderUID string) (*models.Order, error)
+	// GetMany возвращает найденные заказы одним запросом; отсутствующие
+	// order_uid пропускаются. Порядок результата не определен.
+	GetMany(ctx context.Context, orderUIDs []string) ([]models.Order, error)
+	// GetByTrackNumber и GetByTransaction ищут заказ по трек-номеру или
+	// ID транзакции оплаты. Если совпадений несколько, возвращается самый новый.
+	GetByTrackNumber(ctx context.Context, trackNumber string) (*models.Order, error)
+	GetByTransaction(ctx context.Context, transaction string) (*models.Order, error)
+	// Save сохраняет заказ со всеми вложенными сущностями в одной транзакции.
+	// Возвращает ErrAlreadyExists для повторного order_uid и ErrInvalidOrder,
+	// если данные нарушают ограничения схемы.
+	Save(ctx context.Context, order *models.Order) error
+	// SaveWithMessage сохраняет заказ вместе с исходным сообщением Kafka
+	// в одной транзакции.
+	SaveWithMessage(ctx context.Context, order *models.Order, msg *models.OrderMessage) error
+	// GetMessage возвращает исходное сообщение Kafka заказа или ErrNotFound.
+	GetMessage(ctx context.Context, orderUID string) (*models.OrderMessage, error)
+	// List возвращает до limit последних заказов по дате создания.
+	List(ctx context.Context, limit int) ([]models.Order, error)
+	// Find возвращает страницу заказов по фильтру, от новых к старым.
+	// Возвращает ErrInvalidCursor, если курсор поврежден.
+	Find(ctx context.Context, q OrderQuery) (OrderPage, error)
+	// CustomerSummary возвращает сводку по заказам клиента.
+	// Для клиента без заказов OrderCount равен 0.
+	CustomerSummary(ctx context.Context, customerID string) (CustomerSummary, error)
+	// Search ищет заказы по названию и бренду товаров, имени, городу
+	// и телефону получателя. Результаты отсортированы по убыванию Score.
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+	// Delete удаляет заказ или возвращает ErrNotFound.
+	Delete(ctx context.Context, orderUID string) error
+}
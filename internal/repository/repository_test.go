@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/models"
+)
+
+func testOrder(uid string, created time.Time) *models.Order {
+	return &models.Order{
+		OrderUID:    uid,
+		TrackNumber: "TRACK-" + uid,
+		DateCreated: created,
+		Delivery:    models.Delivery{Name: "Ivan", City: "Moscow"},
+		Payment:     models.Payment{Transaction: uid, Currency: "RUB", Amount: models.NewMoney(10000, "RUB"), GoodsTotal: models.NewMoney(10000, "RUB")},
+		Items:       []models.Items{{Name: "item", Price: models.NewMoney(10000, "RUB"), TotalPrice: models.NewMoney(10000, "RUB")}},
+	}
+}
+
+// testRepository проверяет общий контракт OrderRepository.
+func testRepository(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := repo.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	for i, uid := range []string{"repo-a", "repo-b", "repo-c"} {
+		if err := repo.Save(ctx, testOrder(uid, base.Add(time.Duration(i)*time.Hour))); err != nil {
+			t.Fatalf("Save %s failed: %v", uid, err)
+		}
+	}
+	if err := repo.Save(ctx, testOrder("repo-a", base)); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists for duplicate order, got %v", err)
+	}
+
+	got, err := repo.Get(ctx, "repo-b")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Delivery.City != "Moscow" || got.Payment.Amount != models.NewMoney(10000, "RUB") || len(got.Items) != 1 {
+		t.Errorf("Relations not loaded: %+v", got)
+	}
+
+	byTrack, err := repo.GetByTrackNumber(ctx, "TRACK-repo-c")
+	if err != nil || byTrack.OrderUID != "repo-c" {
+		t.Errorf("Expected repo-c by track number, got %v (%v)", byTrack, err)
+	}
+	byTx, err := repo.GetByTransaction(ctx, "repo-a")
+	if err != nil || byTx.OrderUID != "repo-a" || len(byTx.Items) != 1 {
+		t.Errorf("Expected repo-a with items by transaction, got %v (%v)", byTx, err)
+	}
+	if _, err := repo.GetByTransaction(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown transaction, got %v", err)
+	}
+
+	// При одинаковой дате самым новым считается заказ с большим order_uid
+	for _, uid := range []string{"repo-t2", "repo-t1"} {
+		order := testOrder(uid, base)
+		order.TrackNumber = "TRACK-TIE"
+		if err := repo.Save(ctx, order); err != nil {
+			t.Fatalf("Save %s failed: %v", uid, err)
+		}
+	}
+	if tie, err := repo.GetByTrackNumber(ctx, "TRACK-TIE"); err != nil || tie.OrderUID != "repo-t2" {
+		t.Errorf("Expected repo-t2 on equal dates, got %v (%v)", tie, err)
+	}
+
+	many, err := repo.GetMany(ctx, []string{"repo-a", "missing", "repo-c"})
+	if err != nil {
+		t.Fatalf("GetMany failed: %v", err)
+	}
+	if len(many) != 2 || len(many[0].Items) != 1 || len(many[1].Items) != 1 {
+		t.Errorf("Expected two orders with items, got %+v", many)
+	}
+
+	list, err := repo.List(ctx, 2)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 || list[0].OrderUID != "repo-c" || list[1].OrderUID != "repo-b" {
+		t.Errorf("Expected newest two orders, got %+v", list)
+	}
+
+	if _, err := repo.GetMessage(ctx, "repo-b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for order without message, got %v", err)
+	}
+	msg := &models.OrderMessage{
+		Topic:     "order",
+		Partition: 1,
+		Offset:    7,
+		Key:       "repo-d",
+		Headers:   models.MessageHeaders{{Key: "traceparent", Value: "00-abc"}, models.NewMessageHeader("checksum", []byte{0xff})},
+		Payload:   []byte(`{"order_uid": "repo-d"}`),
+		// jsonb переписывает пробелы и повторы ключей, копия должна их сохранить
+		RawPayload: []byte(`{"order_uid":  "repo-d", "order_uid": "repo-d"}`),
+		Timestamp:  base,
+	}
+	if err := repo.SaveWithMessage(ctx, testOrder("repo-d", base), msg); err != nil {
+		t.Fatalf("SaveWithMessage failed: %v", err)
+	}
+	raw, err := repo.GetMessage(ctx, "repo-d")
+	if err != nil {
+		t.Fatalf("GetMessage failed: %v", err)
+	}
+	if raw.Offset != 7 || raw.Key != "repo-d" || len(raw.Headers) != 2 || raw.Headers[0].Value != "00-abc" {
+		t.Errorf("Unexpected message: %+v", raw)
+	}
+	if string(raw.RawPayload) != string(msg.RawPayload) {
+		t.Errorf("Expected verbatim payload %s, got %s", msg.RawPayload, raw.RawPayload)
+	}
+	if value, err := raw.Headers[len(raw.Headers)-1].Bytes(); err != nil || string(value) != "\xff" {
+		t.Errorf("Expected binary header to survive, got %+v", raw.Headers)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(raw.Payload, &payload); err != nil || payload["order_uid"] != "repo-d" {
+		t.Errorf("Unexpected payload %s (%v)", raw.Payload, err)
+	}
+	if err := repo.Delete(ctx, "repo-d"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetMessage(ctx, "repo-d"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected message to be deleted with order, got %v", err)
+	}
+
+	if err := repo.Delete(ctx, "repo-b"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.Get(ctx, "repo-b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := repo.Delete(ctx, "repo-b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for second delete, got %v", err)
+	}
+}
+
+// testRepositoryFind проверяет фильтры и постраничную выдачу Find.
+// Все заказы принадлежат отдельному клиенту, чтобы не зависеть от других данных в базе.
+func testRepositoryFind(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	base := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	const customer = "repo-find-customer"
+
+	for i, uid := range []string{"repo-f1", "repo-f2", "repo-f3", "repo-f4", "repo-f5"} {
+		order := testOrder(uid, base.Add(time.Duration(i/2)*time.Hour))
+		order.CustomerId = customer
+		order.DeliveryService = "meest"
+		order.Locale = "en"
+		if i%2 == 0 {
+			order.DeliveryService = "dhl"
+			order.Items[0].Brand = "Vivienne Sabo"
+		}
+		if err := repo.Save(ctx, order); err != nil {
+			t.Fatalf("Save %s failed: %v", uid, err)
+		}
+	}
+
+	// Обходим все страницы по 2 заказа
+	var got []string
+	q := OrderQuery{Filter: OrderFilter{CustomerID: customer}, Limit: 2, WithTotal: true}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Too many pages")
+		}
+		page, err := repo.Find(ctx, q)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if page.Total == nil || *page.Total != 5 {
+			t.Errorf("Expected total 5, got %v", page.Total)
+		}
+		for _, order := range page.Orders {
+			got = append(got, order.OrderUID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	want := []string{"repo-f5", "repo-f4", "repo-f3", "repo-f2", "repo-f1"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+
+	filters := map[string]struct {
+		filter OrderFilter
+		want   int
+	}{
+		"delivery service": {OrderFilter{DeliveryService: "dhl"}, 3},
+		"brand":            {OrderFilter{Brand: "Vivienne Sabo"}, 3},
+		"track number":     {OrderFilter{TrackNumber: "TRACK-repo-f2"}, 1},
+		"locale":           {OrderFilter{Locale: "ru"}, 0},
+		"created range":    {OrderFilter{CreatedFrom: base.Add(time.Hour), CreatedTo: base.Add(2 * time.Hour)}, 2},
+	}
+	for name, tc := range filters {
+		tc.filter.CustomerID = customer
+		page, err := repo.Find(ctx, OrderQuery{Filter: tc.filter})
+		if err != nil {
+			t.Fatalf("%s: Find failed: %v", name, err)
+		}
+		if len(page.Orders) != tc.want {
+			t.Errorf("%s: expected %d orders, got %d", name, tc.want, len(page.Orders))
+		}
+		if page.Total != nil {
+			t.Errorf("%s: total must be empty without WithTotal", name)
+		}
+	}
+
+	summary, err := repo.CustomerSummary(ctx, customer)
+	if err != nil {
+		t.Fatalf("CustomerSummary failed: %v", err)
+	}
+	if summary.OrderCount != 5 || len(summary.TotalSpent) != 1 || summary.TotalSpent[0] != models.NewMoney(50000, "RUB") {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if !summary.FirstOrderAt.Equal(base) || !summary.LastOrderAt.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("Unexpected summary dates: %v - %v", summary.FirstOrderAt, summary.LastOrderAt)
+	}
+	if summary, err := repo.CustomerSummary(ctx, "repo-nobody"); err != nil || summary.OrderCount != 0 {
+		t.Errorf("Expected empty summary, got %+v (%v)", summary, err)
+	}
+
+	results, err := repo.Search(ctx, "vivienne", 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	found := 0
+	for _, r := range results {
+		if r.Order.CustomerId != customer {
+			continue
+		}
+		found++
+		if len(r.Matches) == 0 || r.Matches[0].Field != FieldItemBrand || r.Score <= 1 {
+			t.Errorf("Unexpected search result for %s: %+v", r.Order.OrderUID, r)
+		}
+		if len(r.Order.Items) != 1 {
+			t.Errorf("Search result without relations: %+v", r.Order)
+		}
+	}
+	if found != 3 {
+		t.Errorf("Expected 3 orders with brand match, got %d", found)
+	}
+
+	// Заказ с множеством совпавших товаров не вытесняет остальные из выдачи
+	crowded := testOrder("repo-s1", base)
+	crowded.Items = nil
+	for range 25 {
+		crowded.Items = append(crowded.Items, models.Items{Name: "Zebralamp", Price: models.NewMoney(100, "RUB"), TotalPrice: models.NewMoney(100, "RUB")})
+	}
+	single := testOrder("repo-s2", base)
+	single.Items[0].Name = "Zebralamp deluxe"
+	for _, order := range []*models.Order{crowded, single} {
+		if err := repo.Save(ctx, order); err != nil {
+			t.Fatalf("Save %s failed: %v", order.OrderUID, err)
+		}
+	}
+	results, err = repo.Search(ctx, "zebralamp", 2)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Order.OrderUID != "repo-s1" || results[1].Order.OrderUID != "repo-s2" {
+		t.Fatalf("Expected repo-s1 and repo-s2, got %+v", results)
+	}
+	if len(results[0].Matches) != 25 {
+		t.Errorf("Expected all 25 matches of repo-s1, got %d", len(results[0].Matches))
+	}
+
+	if _, err := repo.Find(ctx, OrderQuery{Cursor: "not-a-cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testRepository(t, NewMemory())
+	testRepositoryFind(t, NewMemory())
+}
+
+func TestMemoryReturnsCopies(t *testing.T) {
+	repo := NewMemory()
+	order := testOrder("copy", time.Now())
+	if err := repo.Save(context.Background(), order); err != nil {
+		t.Fatal(err)
+	}
+	order.Items[0].Name = "changed"
+
+	got, _ := repo.Get(context.Background(), "copy")
+	if got.Items[0].Name != "item" {
+		t.Error("Memory repository shares items with caller")
+	}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gegxkss/wbL0/internal/models"
+)
+
+// Поля, по которым идет поиск.
+const (
+	FieldItemName      = "items.name"
+	FieldItemBrand     = "items.brand"
+	FieldDeliveryName  = "delivery.name"
+	FieldDeliveryCity  = "delivery.city"
+	FieldDeliveryPhone = "delivery.phone"
+)
+
+// SearchMatch — значение поля, совпавшее с запросом.
+type SearchMatch struct {
+	Field string
+	Value string
+	Score float64
+}
+
+type SearchResult struct {
+	Order models.Order
+	// Score — лучшая оценка среди совпадений: точное вхождение подстроки
+	// дает больше 1, нечеткое (по триграммам) — от 0 до 1.
+	Score   float64
+	Matches []SearchMatch
+}
+
+// searchRow — строка выборки совпадений до группировки по заказам.
+type searchRow struct {
+	OrderUID string
+	SearchMatch
+}
+
+// groupMatches собирает совпадения по заказам, сохраняя порядок по убыванию
+// оценки, и оставляет не больше limit заказов.
+func groupMatches(rows []searchRow, limit int) ([]string, map[string][]SearchMatch) {
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Score > rows[j].Score })
+
+	var uids []string
+	matches := make(map[string][]SearchMatch)
+	for _, row := range rows {
+		if _, seen := matches[row.OrderUID]; !seen {
+			if len(uids) == limit {
+				continue
+			}
+			uids = append(uids, row.OrderUID)
+		}
+		matches[row.OrderUID] = append(matches[row.OrderUID], row.SearchMatch)
+	}
+	return uids, matches
+}
+
+func searchResults(uids []string, orders map[string]models.Order, matches map[string][]SearchMatch) []SearchResult {
+	results := make([]SearchResult, 0, len(uids))
+	for _, uid := range uids {
+		order, ok := orders[uid]
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{Order: order, Score: matches[uid][0].Score, Matches: matches[uid]})
+	}
+	return results
+}
+
+// substringScore оценивает совпадение для хранилища в памяти:
+// 1 плюс доля запроса в значении, если запрос входит в значение.
+func substringScore(query, value string) (float64, bool) {
+	if value == "" || !strings.Contains(strings.ToLower(value), strings.ToLower(query)) {
+		return 0, false
+	}
+	return 1 + float64(len(query))/float64(len(value)), true
+}
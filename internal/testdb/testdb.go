@@ -0,0 +1,81 @@
+// Package testdb подключает тесты к отдельной базе PostgreSQL.
+// Если переменная TEST_DB_URL не задана, такие тесты пропускаются,
+// поэтому go test ./... проходит без запущенной базы.
+package testdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gegxkss/wbL0/internal/config"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"gorm.io/gorm"
+)
+
+const envKey = "TEST_DB_URL"
+
+func Open(t *testing.T) *gorm.DB {
+	t.Helper()
+	return open(t, dsn(t))
+}
+
+// OpenClean подключается к новой пустой схеме, которая удаляется после теста.
+// Нужна тестам миграций, которым важно начинать с чистой базы.
+func OpenClean(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	admin := Open(t)
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE")
+	})
+
+	return open(t, withSearchPath(dsn(t), schema))
+}
+
+func dsn(t *testing.T) string {
+	t.Helper()
+
+	dsn := os.Getenv(envKey)
+	if dsn == "" {
+		t.Skipf("%s not set, skipping database test", envKey)
+	}
+	return dsn
+}
+
+func open(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+
+	cfg := config.Default().Database
+	cfg.DSN = dsn
+	cfg.ConnectAttempts = 1
+	db, err := config.OpenDB(context.Background(), cfg, logger.Nop())
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// withSearchPath добавляет search_path к DSN в формате URL или key=value.
+func withSearchPath(dsn, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && strings.HasPrefix(u.Scheme, "postgres") {
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return dsn + " search_path=" + schema
+}
@@ -3,28 +3,44 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/gegxkss/wbL0/internal/broadcast"
 	"github.com/gegxkss/wbL0/internal/cache"
 	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
 	"github.com/segmentio/kafka-go"
-	"gorm.io/gorm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Consumer struct {
 	reader   *kafka.Reader
-	db       *gorm.DB
+	repo     repository.OrderRepository
 	stopChan chan struct{}
 	cache    *cache.Cache
 	ctx      context.Context
 	cancel   context.CancelFunc
+	log      *slog.Logger
+	running  sync.WaitGroup
+	stopOnce sync.Once
+	// broadcaster получает сохраненные заказы для подписчиков; может быть nil.
+	broadcaster *broadcast.Broadcaster
+	state       *readerState
 }
 
-func NewConsumer(address []string, topic, groupID string, db *gorm.DB, cache *cache.Cache) (*Consumer, error) {
-	log.Printf("Connecting to Kafka brokers: %v", address)
+func NewConsumer(address []string, topic, groupID string, repo repository.OrderRepository, cache *cache.Cache, log *slog.Logger) (*Consumer, error) {
+	log = log.With("component", "consumer", "topic", topic, "group_id", groupID)
+	log.Info("connecting to kafka", "brokers", address)
 	config := kafka.ReaderConfig{
 		Brokers:  address,
 		Topic:    topic,
@@ -40,17 +56,39 @@ func NewConsumer(address []string, topic, groupID string, db *gorm.DB, cache *ca
 
 	return &Consumer{
 		reader:   reader,
-		db:       db,
+		repo:     repo,
 		stopChan: make(chan struct{}),
 		cache:    cache,
 		ctx:      ctx,
 		cancel:   cancel,
+		log:      log,
+		state:    newReaderState(),
 	}, nil
 }
 
+// SetBroadcaster включает рассылку сохраненных заказов подписчикам.
+// Вызывается до Start.
+func (c *Consumer) SetBroadcaster(b *broadcast.Broadcaster) {
+	c.broadcaster = b
+}
+
+// Start запускает чтение сообщений в отдельной горутине. Счетчик running
+// увеличивается до ее запуска, поэтому Stop, вызванный сразу после Start,
+// дождется завершения чтения.
 func (c *Consumer) Start() {
-	log.Println("Starting Kafka consumer")
-	defer log.Println("Kafka consumer stopped")
+	c.state.start()
+	c.running.Add(1)
+	go c.run()
+}
+
+func (c *Consumer) run() {
+	defer c.running.Done()
+
+	c.log.Info("consumer started")
+	defer c.log.Info("consumer stopped")
+	defer c.state.stop()
+
+	go c.collectStats()
 
 	for {
 		select {
@@ -66,108 +104,168 @@ func (c *Consumer) Start() {
 					continue
 				}
 				if strings.Contains(err.Error(), "context canceled") {
-					log.Println("Consumer context canceled, stopping")
+					c.log.Info("consumer context canceled")
 					return
 				}
-				log.Printf("Consumer error: %v", err)
+				messageErrors.WithLabelValues("read").Inc()
+				c.log.Error("read message failed", "error", err)
+				c.state.failed(err)
 				continue
 			}
+			c.state.fetched()
 
+			mlog := c.log.With("partition", msg.Partition, "offset", msg.Offset)
 			if len(msg.Value) == 0 {
-				log.Println("We got empty message, continue")
+				messageErrors.WithLabelValues("empty").Inc()
+				mlog.Warn("empty message skipped")
 				continue
 			}
 
-			if err := c.processMessage(msg.Value); err != nil {
-				log.Printf("Failed to process message: %v", err)
+			started := time.Now()
+			if err := c.processMessage(msg, mlog); err != nil {
+				stage := errorStage(err)
+				messageErrors.WithLabelValues(stage).Inc()
+				if stage == "duplicate" {
+					// Повторная доставка того же заказа — не ошибка, сообщение пропускается.
+					mlog.Warn("order already exists, message skipped", "error", err)
+					continue
+				}
+				mlog.Error("process message failed", "stage", stage, "error", err)
 				continue
 			}
+			observeMessage(msg, time.Since(started))
 
-			log.Printf("Message processed successfully for topic %s, partition %d, offset %d",
-				msg.Topic, msg.Partition, msg.Offset)
+			mlog.Debug("message processed", "duration", time.Since(started))
 		}
 	}
 }
 
-func (c *Consumer) processMessage(data []byte) error {
-	var order models.Order
-	if err := json.Unmarshal(data, &order); err != nil {
-		return fmt.Errorf("unmarshal error: %w", err)
+func (c *Consumer) collectStats() {
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			stats := c.reader.Stats()
+			reportStats(stats)
+			c.state.observe(stats)
+		}
 	}
+}
 
-	log.Printf("Received order: %s, items count: %d", order.OrderUID, len(order.Items))
+func (c *Consumer) processMessage(msg kafka.Message, log *slog.Logger) (err error) {
+	// Остановка консьюмера не должна обрывать транзакцию посреди записи,
+	// поэтому обработка идет в контексте без отмены.
+	ctx := otel.GetTextMapPropagator().Extract(context.WithoutCancel(c.ctx), headerCarrier{&msg.Headers})
+	ctx, span := tracer.Start(ctx, "kafka.process "+msg.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(msg.Topic),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(msg.Partition)),
+			semconv.MessagingKafkaOffset(int(msg.Offset)),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
 
-	if order.OrderUID == "" {
-		return fmt.Errorf("invalid order: order_uid is empty")
+	var order models.Order
+	if err := json.Unmarshal(msg.Value, &order); err != nil {
+		return fmt.Errorf("unmarshal error: %w", err)
 	}
 
-	tx := c.db.Begin()
-
-	// Сохраняем заказ
-	orderToSave := models.Order{
-		OrderUID:          order.OrderUID,
-		TrackNumber:       order.TrackNumber,
-		Entry:             order.Entry,
-		Locale:            order.Locale,
-		InternalSignature: order.InternalSignature,
-		CustomerId:        order.CustomerId,
-		DeliveryService:   order.DeliveryService,
-		ShardKey:          order.ShardKey,
-		SmId:              order.SmId,
-		DateCreated:       order.DateCreated,
-		OofShard:          order.OofShard,
+	if err := order.Validate(); err != nil {
+		return err
 	}
+	span.SetAttributes(attribute.String("order.uid", order.OrderUID))
+	log = log.With("order_uid", order.OrderUID)
+	log.Debug("order received", "items", len(order.Items))
 
-	if err := tx.Create(&orderToSave).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("create order failed: %w", err)
+	if err := c.repo.SaveWithMessage(ctx, &order, orderMessage(msg)); err != nil {
+		return err
 	}
 
-	// Сохраняем доставку
-	delivery := order.Delivery
-	delivery.ID = 0
-	delivery.OrderUID = order.OrderUID
-	if err := tx.Create(&delivery).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("create delivery failed: %w", err)
+	// Сохраняем в кэш оригинальный order
+	if err := c.cache.Set(order.OrderUID, &order); err != nil {
+		log.Warn("add order to cache failed", "error", err)
 	}
-
-	// Сохраняем товары
-	for i := range order.Items {
-		item := order.Items[i]
-		item.ID = 0
-		item.OrderUID = order.OrderUID
-		if err := tx.Create(&item).Error; err != nil {
-			tx.Rollback()
-			return fmt.Errorf("create item failed: %w", err)
-		}
+	if c.broadcaster != nil {
+		c.broadcaster.Publish(&order)
 	}
 
-	// Сохраняем оплату
-	payment := order.Payment
-	payment.ID = 0
-	payment.OrderUID = order.OrderUID
-	if err := tx.Create(&payment).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("create payment failed: %w", err)
-	}
+	log.Info("order saved")
+	return nil
+}
 
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("commit transaction failed: %w", err)
-	}
+// Ping сообщает о готовности по состоянию reader'а: консьюмер запущен,
+// не остановлен и недавно получал данные от брокера.
+func (c *Consumer) Ping(context.Context) error {
+	return c.state.check()
+}
 
-	// Сохраняем в кэш оригинальный order
-	log.Printf("Saving order to cache: %s", order.OrderUID)
-	if err := c.cache.Set(order.OrderUID, &order); err != nil {
-		log.Printf("Warning: failed to add order to cache: %v", err)
+// Stop прерывает ожидание новых сообщений, дожидается обработки текущего
+// и закрывает reader. Если ctx истекает раньше, reader закрывается сразу.
+// Повторный вызов безопасен.
+func (c *Consumer) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() {
+		c.state.stop()
+		close(c.stopChan)
+		c.cancel()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		c.running.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("wait for current message: %w", ctx.Err())
 	}
 
-	log.Printf("Successfully saved order %s", order.OrderUID)
-	return nil
+	return errors.Join(err, c.reader.Close())
 }
 
-func (c *Consumer) Stop() {
-	close(c.stopChan)
-	c.cancel()
-	c.reader.Close()
+// errorStage возвращает метку для kafka_consumer_errors_total:
+// duplicate — заказ уже сохранен, invalid — заказ не прошел проверку
+// или нарушает ограничения схемы.
+func errorStage(err error) string {
+	switch {
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return "duplicate"
+	case errors.Is(err, repository.ErrInvalidOrder), errors.Is(err, models.ErrValidation):
+		return "invalid"
+	default:
+		return "process"
+	}
+}
+
+// orderMessage сохраняет исходное сообщение вместе с заказом,
+// чтобы его можно было посмотреть через GET /order/{id}/raw.
+func orderMessage(msg kafka.Message) *models.OrderMessage {
+	headers := make(models.MessageHeaders, 0, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers = append(headers, models.NewMessageHeader(h.Key, h.Value))
+	}
+	return &models.OrderMessage{
+		Topic:      msg.Topic,
+		Partition:  msg.Partition,
+		Offset:     msg.Offset,
+		Key:        string(msg.Key),
+		Headers:    headers,
+		Payload:    msg.Value,
+		RawPayload: msg.Value,
+		Timestamp:  msg.Time,
+	}
 }
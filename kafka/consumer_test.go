@@ -1,19 +1,34 @@
 package kafka
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/gegxkss/wbL0/internal/broadcast"
 	"github.com/gegxkss/wbL0/internal/cache"
-	"gorm.io/gorm"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"github.com/segmentio/kafka-go"
 )
 
-func TestNewConsumer(t *testing.T) {
-	cache := cache.NewCache()
-	var db *gorm.DB
-	c, err := NewConsumer([]string{"localhost:9091"}, "order", "group", db, cache)
+func newTestConsumer(t *testing.T) (*Consumer, *repository.Memory, *cache.Cache) {
+	t.Helper()
+	repo := repository.NewMemory()
+	c := cache.NewCache(logger.Nop())
+	consumer, err := NewConsumer([]string{"localhost:9091"}, "order", "group", repo, c, logger.Nop())
 	if err != nil {
 		t.Fatalf("Consumer creation failed: %v", err)
 	}
+	t.Cleanup(func() { consumer.reader.Close() })
+	return consumer, repo, c
+}
+
+func TestNewConsumer(t *testing.T) {
+	c, _, cache := newTestConsumer(t)
 	if c.reader == nil {
 		t.Error("Reader is nil")
 	}
@@ -21,3 +36,157 @@ func TestNewConsumer(t *testing.T) {
 		t.Error("Cache not set")
 	}
 }
+
+func TestProcessMessage_SavesAndCachesOrder(t *testing.T) {
+	c, repo, cache := newTestConsumer(t)
+	msg := kafka.Message{
+		Topic:     "order",
+		Partition: 2,
+		Offset:    42,
+		Key:       []byte("uid-1"),
+		Headers:   []kafka.Header{{Key: "source", Value: []byte("test")}, {Key: "checksum", Value: []byte{0xff, 0x00}}},
+		Value:     []byte(`{"order_uid":"uid-1","track_number":"TRACK","delivery":{"name":"Ivan"},"payment":{"currency":"RUB","amount":100,"goods_total":100},"items":[{"name":"item","price":100,"total_price":100}]}`),
+	}
+
+	if err := c.processMessage(msg, logger.Nop()); err != nil {
+		t.Fatalf("processMessage failed: %v", err)
+	}
+
+	saved, err := repo.Get(context.Background(), "uid-1")
+	if err != nil {
+		t.Fatalf("Order not saved: %v", err)
+	}
+	if saved.Delivery.Name != "Ivan" || len(saved.Items) != 1 {
+		t.Errorf("Unexpected saved order: %+v", saved)
+	}
+	raw, err := repo.GetMessage(context.Background(), "uid-1")
+	if err != nil {
+		t.Fatalf("Raw message not saved: %v", err)
+	}
+	if raw.Topic != "order" || raw.Partition != 2 || raw.Offset != 42 || raw.Key != "uid-1" {
+		t.Errorf("Unexpected raw message position: %+v", raw)
+	}
+	if string(raw.Payload) != string(msg.Value) || string(raw.RawPayload) != string(msg.Value) {
+		t.Errorf("Expected payload %s, got %s (raw %s)", msg.Value, raw.Payload, raw.RawPayload)
+	}
+	if len(raw.Headers) != 2 || raw.Headers[0].Key != "source" || raw.Headers[0].Value != "test" || raw.Headers[0].Encoding != "" {
+		t.Fatalf("Unexpected raw headers: %+v", raw.Headers)
+	}
+	if value, err := raw.Headers[1].Bytes(); err != nil || string(value) != "\xff\x00" || raw.Headers[1].Encoding != models.HeaderEncodingBase64 {
+		t.Errorf("Expected binary header to survive, got %+v", raw.Headers[1])
+	}
+	cached, ok := cache.Get("uid-1")
+	if !ok {
+		t.Fatal("Order not cached")
+	}
+	if cached.(*models.Order).TrackNumber != "TRACK" {
+		t.Errorf("Unexpected cached order: %+v", cached)
+	}
+}
+
+func TestProcessMessage_PublishesToBroadcaster(t *testing.T) {
+	c, _, _ := newTestConsumer(t)
+	b := broadcast.New(1, logger.Nop())
+	c.SetBroadcaster(b)
+	sub := b.Subscribe(broadcast.Filter{})
+	defer sub.Close()
+
+	err := c.processMessage(kafka.Message{Value: []byte(`{"order_uid":"uid-1","payment":{"currency":"RUB"}}`)}, logger.Nop())
+	if err != nil {
+		t.Fatalf("processMessage failed: %v", err)
+	}
+	select {
+	case event := <-sub.Events():
+		if event.Order.OrderUID != "uid-1" {
+			t.Errorf("Expected uid-1, got %q", event.Order.OrderUID)
+		}
+	default:
+		t.Fatal("Stored order was not published")
+	}
+
+	// Повторное сообщение не сохраняется и не рассылается
+	c.processMessage(kafka.Message{Value: []byte(`{"order_uid":"uid-1","payment":{"currency":"RUB"}}`)}, logger.Nop())
+	if len(sub.Events()) != 0 {
+		t.Error("Duplicate order was published")
+	}
+}
+
+func TestProcessMessage_RejectsInvalidMessages(t *testing.T) {
+	c, _, _ := newTestConsumer(t)
+	cases := map[string]string{
+		"invalid json":    `{"order_uid":`,
+		"empty order_uid": `{"order_uid":"","payment":{"currency":"RUB"}}`,
+		"amount mismatch": `{"order_uid":"uid-2","payment":{"currency":"RUB","amount":100}}`,
+	}
+	for name, value := range cases {
+		if err := c.processMessage(kafka.Message{Value: []byte(value)}, logger.Nop()); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestProcessMessage_DuplicateOrderIsNotCachedTwice(t *testing.T) {
+	c, repo, cache := newTestConsumer(t)
+	existing := &models.Order{OrderUID: "uid-1", TrackNumber: "OLD"}
+	if err := repo.Save(context.Background(), existing); err != nil {
+		t.Fatal(err)
+	}
+
+	err := c.processMessage(kafka.Message{Value: []byte(`{"order_uid":"uid-1","track_number":"NEW","payment":{"currency":"RUB"}}`)}, logger.Nop())
+	if stage := errorStage(err); stage != "duplicate" {
+		t.Fatalf("Expected duplicate error, got %q (%v)", stage, err)
+	}
+	if _, ok := cache.Get("uid-1"); ok {
+		t.Error("Duplicate order should not be cached")
+	}
+	if got, _ := repo.Get(context.Background(), "uid-1"); got.TrackNumber != "OLD" {
+		t.Errorf("Existing order was overwritten: %+v", got)
+	}
+}
+
+func TestErrorStage(t *testing.T) {
+	cases := map[string]error{
+		"duplicate": fmt.Errorf("create order failed: %w", repository.ErrAlreadyExists),
+		"invalid":   fmt.Errorf("create item failed: %w", repository.ErrInvalidOrder),
+		"process":   errors.New("connection reset"),
+	}
+	for want, err := range cases {
+		if got := errorStage(err); got != want {
+			t.Errorf("Expected stage %q for %v, got %q", want, err, got)
+		}
+	}
+}
+
+func TestConsumerStopWithoutStart(t *testing.T) {
+	c, err := NewConsumer([]string{"localhost:9091"}, "order", "group", repository.NewMemory(), cache.NewCache(logger.Nop()), logger.Nop())
+	if err != nil {
+		t.Fatalf("Consumer creation failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.Stop(ctx); err != nil {
+		t.Errorf("Stop failed: %v", err)
+	}
+}
+
+func TestConsumerStartStop(t *testing.T) {
+	c, err := NewConsumer([]string{"localhost:9091"}, "order", "group", repository.NewMemory(), cache.NewCache(logger.Nop()), logger.Nop())
+	if err != nil {
+		t.Fatalf("Consumer creation failed: %v", err)
+	}
+
+	// Stop сразу после Start дожидается горутины чтения, даже если она еще не запущена
+	c.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Stop(ctx); err != nil {
+		t.Errorf("Stop failed: %v", err)
+	}
+	if err := c.Stop(ctx); err != nil {
+		t.Errorf("Second Stop failed: %v", err)
+	}
+	if err := c.Ping(ctx); !errors.Is(err, errConsumerStopped) {
+		t.Errorf("Expected Ping to fail after Stop, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+)
+
+const statsInterval = 5 * time.Second
+
+var (
+	messagesProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_messages_processed_total",
+		Help: "Number of Kafka messages processed successfully.",
+	}, []string{"topic", "partition"})
+
+	messageErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_errors_total",
+		Help: "Number of consumer errors by stage.",
+	}, []string{"stage"})
+
+	processingDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kafka_consumer_processing_duration_seconds",
+		Help:    "Time spent processing a single Kafka message.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	partitionLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_partition_lag",
+		Help: "Messages between the last processed offset and the partition high watermark.",
+	}, []string{"topic", "partition"})
+
+	readerLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kafka_consumer_reader_lag",
+		Help: "Lag reported by the Kafka reader.",
+	})
+
+	readerMessages = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kafka_consumer_reader_messages_total",
+		Help: "Messages fetched by the Kafka reader.",
+	})
+
+	readerBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kafka_consumer_reader_bytes_total",
+		Help: "Bytes fetched by the Kafka reader.",
+	})
+
+	readerRebalances = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kafka_consumer_reader_rebalances_total",
+		Help: "Consumer group rebalances seen by the Kafka reader.",
+	})
+
+	readerErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kafka_consumer_reader_errors_total",
+		Help: "Errors reported by the Kafka reader.",
+	})
+)
+
+// observeMessage обновляет метрики после обработки сообщения.
+func observeMessage(msg kafka.Message, elapsed time.Duration) {
+	partition := strconv.Itoa(msg.Partition)
+	processingDuration.Observe(elapsed.Seconds())
+	messagesProcessed.WithLabelValues(msg.Topic, partition).Inc()
+
+	lag := msg.HighWaterMark - msg.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	partitionLag.WithLabelValues(msg.Topic, partition).Set(float64(lag))
+}
+
+// reportStats переносит снимок статистики reader'а в метрики.
+// Счетчики в kafka.ReaderStats сбрасываются при каждом вызове Stats,
+// поэтому здесь они прибавляются как приращения.
+func reportStats(stats kafka.ReaderStats) {
+	readerLag.Set(float64(stats.Lag))
+	readerMessages.Add(float64(stats.Messages))
+	readerBytes.Add(float64(stats.Bytes))
+	readerRebalances.Add(float64(stats.Rebalances))
+	readerErrors.Add(float64(stats.Errors))
+}
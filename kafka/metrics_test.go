@@ -0,0 +1,36 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestObserveMessage(t *testing.T) {
+	msg := kafka.Message{Topic: "order", Partition: 2, Offset: 10, HighWaterMark: 15}
+	before := testutil.ToFloat64(messagesProcessed.WithLabelValues("order", "2"))
+
+	observeMessage(msg, 10*time.Millisecond)
+
+	if got := testutil.ToFloat64(messagesProcessed.WithLabelValues("order", "2")); got != before+1 {
+		t.Errorf("Expected %v processed messages, got %v", before+1, got)
+	}
+	if got := testutil.ToFloat64(partitionLag.WithLabelValues("order", "2")); got != 4 {
+		t.Errorf("Expected lag 4, got %v", got)
+	}
+}
+
+func TestReportStats(t *testing.T) {
+	before := testutil.ToFloat64(readerMessages)
+
+	reportStats(kafka.ReaderStats{Lag: 7, Messages: 3})
+
+	if got := testutil.ToFloat64(readerLag); got != 7 {
+		t.Errorf("Expected reader lag 7, got %v", got)
+	}
+	if got := testutil.ToFloat64(readerMessages); got != before+3 {
+		t.Errorf("Expected %v reader messages, got %v", before+3, got)
+	}
+}
@@ -6,6 +6,10 @@ import (
 	"time"
 
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -30,18 +34,37 @@ func NewProducer(address []string) (*Producer, error) {
 }
 
 func (p *Producer) Produce(message, topic, key string) error {
+	return p.ProduceContext(context.Background(), message, topic, key)
+}
+
+// ProduceContext отправляет сообщение и передает trace context из ctx
+// в заголовках сообщения.
+func (p *Producer) ProduceContext(ctx context.Context, message, topic, key string) error {
+	ctx, span := tracer.Start(ctx, "kafka.produce "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingKafkaMessageKey(key),
+		),
+	)
+	defer span.End()
+
 	kafkaMsg := kafka.Message{
 		Topic: topic,
 		Value: []byte(message),
 		Key:   []byte(key),
 		Time:  time.Now(),
 	}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{&kafkaMsg.Headers})
 
-	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
 	defer cancel()
 
 	err := p.writer.WriteMessages(ctx, kafkaMsg)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("error producing message: %w", err)
 	}
 
@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fetchStaleAfter — сколько reader может не выполнять запросы fetch, прежде
+// чем консьюмер считается неготовым. Больше MaxWait, чтобы пустой топик
+// не считался ошибкой.
+const fetchStaleAfter = time.Minute
+
+var (
+	errConsumerNotStarted = errors.New("kafka consumer is not started")
+	errConsumerStopped    = errors.New("kafka consumer is stopped")
+)
+
+// readerState отслеживает работу reader'а для проверки готовности:
+// когда он последний раз получал данные от брокера и с какой ошибкой
+// завершилась последняя неудачная попытка.
+type readerState struct {
+	mu        sync.Mutex
+	started   bool
+	stopped   bool
+	lastFetch time.Time
+	lastErr   error
+	now       func() time.Time
+}
+
+func newReaderState() *readerState {
+	return &readerState{now: time.Now}
+}
+
+func (s *readerState) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = true
+}
+
+func (s *readerState) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+}
+
+// fetched отмечает успешное чтение от брокера.
+func (s *readerState) fetched() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastFetch = s.now()
+	s.lastErr = nil
+}
+
+func (s *readerState) failed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+}
+
+// observe учитывает снимок статистики reader'а. Запросы fetch выполняются
+// только после входа в группу и подключения к лидеру партиции, поэтому
+// их наличие означает, что reader работает, даже если топик пуст.
+func (s *readerState) observe(stats kafka.ReaderStats) {
+	switch {
+	case stats.Fetches > stats.Errors:
+		s.fetched()
+	case stats.Errors > 0:
+		s.failed(fmt.Errorf("kafka reader reported %d errors", stats.Errors))
+	}
+}
+
+// check возвращает ошибку, если reader остановлен, еще не получал данных
+// от брокера или не получал их дольше fetchStaleAfter.
+func (s *readerState) check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.stopped:
+		return errConsumerStopped
+	case !s.started:
+		return errConsumerNotStarted
+	case !s.lastFetch.IsZero() && s.now().Sub(s.lastFetch) < fetchStaleAfter:
+		return nil
+	case s.lastErr != nil:
+		return fmt.Errorf("kafka reader: %w", s.lastErr)
+	case s.lastFetch.IsZero():
+		return errors.New("kafka reader has not fetched yet")
+	default:
+		return fmt.Errorf("kafka reader has not fetched for %s", s.now().Sub(s.lastFetch).Round(time.Second))
+	}
+}
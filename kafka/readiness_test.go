@@ -0,0 +1,50 @@
+package kafka
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestReaderStateCheck(t *testing.T) {
+	s := newReaderState()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	if err := s.check(); !errors.Is(err, errConsumerNotStarted) {
+		t.Errorf("Expected errConsumerNotStarted, got %v", err)
+	}
+	s.start()
+	if err := s.check(); err == nil {
+		t.Error("Expected error before the first fetch")
+	}
+
+	// Reader не может войти в группу: есть только ошибки
+	s.observe(kafka.ReaderStats{Dials: 3, Errors: 3})
+	if err := s.check(); err == nil || !strings.Contains(err.Error(), "3 errors") {
+		t.Errorf("Expected reader errors, got %v", err)
+	}
+
+	// Пустой топик: fetch выполняются, сообщений нет
+	s.observe(kafka.ReaderStats{Fetches: 1})
+	if err := s.check(); err != nil {
+		t.Errorf("Expected ready after fetch, got %v", err)
+	}
+
+	now = now.Add(fetchStaleAfter + time.Second)
+	if err := s.check(); err == nil {
+		t.Error("Expected error when reader stopped fetching")
+	}
+	s.fetched()
+	if err := s.check(); err != nil {
+		t.Errorf("Expected ready after message, got %v", err)
+	}
+
+	s.stop()
+	if err := s.check(); !errors.Is(err, errConsumerStopped) {
+		t.Errorf("Expected errConsumerStopped, got %v", err)
+	}
+}
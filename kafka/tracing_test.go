@@ -0,0 +1,32 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHeaderCarrierPropagatesTraceContext(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "produce")
+	defer span.End()
+
+	var headers []kafka.Header
+	propagator := propagation.TraceContext{}
+	propagator.Inject(ctx, headerCarrier{&headers})
+
+	if len(headers) == 0 || headers[0].Key != "traceparent" {
+		t.Fatalf("Expected traceparent header, got %v", headers)
+	}
+
+	extracted := trace.SpanContextFromContext(propagator.Extract(context.Background(), headerCarrier{&headers}))
+	if extracted.TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("Expected trace ID %s, got %s", span.SpanContext().TraceID(), extracted.TraceID())
+	}
+}
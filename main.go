@@ -1,75 +1,205 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/gegxkss/wbL0/internal/broadcast"
 	"github.com/gegxkss/wbL0/internal/cache"
 	"github.com/gegxkss/wbL0/internal/config"
+	"github.com/gegxkss/wbL0/internal/grpcserver"
 	"github.com/gegxkss/wbL0/internal/handlers"
-	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/health"
+	"github.com/gegxkss/wbL0/internal/lifecycle"
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/repository"
+	"github.com/gegxkss/wbL0/internal/tracing"
 	"github.com/gegxkss/wbL0/kafka"
-	"github.com/gegxkss/wbL0/migrations"
-	"gorm.io/gorm"
 )
 
-var migrate = flag.Bool("m", false, "Run database migrations")
+var migrate = flag.Bool("m", false, "Apply all database migrations and exit (same as: migrate up)")
 
-const (
-	topic   = "order"
-	groupID = "orders-group"
-)
-
-var kafkaAddresses = []string{"localhost:9091", "localhost:9092", "localhost:9093"}
+// version задается при сборке: -ldflags "-X main.version=..."
+var version = "dev"
 
 func main() {
-	flag.Parse()
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.Getenv)
+	if err != nil {
+		slog.Error("load config failed", "error", err)
+		os.Exit(1)
+	}
+
+	log, err := logger.New(os.Stdout, cfg.Log.Level)
+	if err != nil {
+		slog.Error("invalid log level", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(log)
+	log.Info("effective config", "config", cfg.Redacted())
+
+	db, err := config.OpenDB(context.Background(), cfg.Database, log)
+	if err != nil {
+		log.Error("open database failed", "error", err)
+		os.Exit(1)
+	}
+	log.Info("connected to database")
 
+	// Подкоманда migrate: go run . migrate up|down|status|to <version>
+	args := flag.Args()
 	if *migrate {
-		migrations.Migration()
-		log.Println("Migrations completed. Exiting.")
+		args = []string{"migrate", "up"}
+	}
+	if len(args) > 0 {
+		if args[0] != "migrate" {
+			log.Error("unknown command", "command", args[0])
+			os.Exit(2)
+		}
+		if err := runMigrate(context.Background(), db, args[1:], os.Stdout, log); err != nil {
+			log.Error("migrations failed", "error", err)
+			os.Exit(1)
+		}
+		log.Info("migrations completed, exiting")
 		return
 	}
 
-	config.ConnectDB()
-	cache := cache.NewCache()
-	restoreCacheFromDB(config.DB, cache)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "wbl0",
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+	})
+	if err != nil {
+		log.Error("set up tracing failed", "error", err)
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	checker := health.NewChecker(version)
+	checker.Routes(mux)
 
-	consumer, _ := kafka.NewConsumer(kafkaAddresses, topic, groupID, config.DB, cache)
-	go consumer.Start()
-	defer consumer.Stop()
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Error("get database pool failed", "error", err)
+		os.Exit(1)
+	}
+	checker.Register("database", sqlDB.PingContext)
+	checker.SetDetails("database", func() any { return config.NewPoolStats(sqlDB.Stats()) })
+	var dbVersion string
+	if err := db.Raw("SHOW server_version").Scan(&dbVersion).Error; err == nil {
+		checker.SetVersion("database", dbVersion)
+	}
 
-	handlers.SetupRoutes(cache, config.DB)
+	repo := repository.NewPostgres(db)
+	cache := cache.NewCacheWithSize(cfg.Cache.Size, log.With("component", "cache"))
+	checker.Register("cache", func(context.Context) error {
+		if !cache.Warm() {
+			return errors.New("cache warm-up in progress")
+		}
+		return nil
+	})
+
+	consumer, _ := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Kafka.Topic, cfg.Kafka.GroupID, repo, cache, log)
+	checker.Register("kafka", consumer.Ping)
+	broadcaster := broadcast.New(broadcast.DefaultBuffer, log)
+	consumer.SetBroadcaster(broadcaster)
+
+	producer, err := kafka.NewProducer(cfg.Kafka.Brokers)
+	if err != nil {
+		log.Error("create kafka producer failed", "error", err)
+		os.Exit(1)
+	}
+	handlers.SetupRoutes(mux, cache, repo, handlers.NewIngest(producer, cfg.Kafka.Topic), broadcaster, log)
+	server := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// Потоки SSE не завершаются сами, поэтому при остановке подписки закрываются
+	server.RegisterOnShutdown(broadcaster.Close)
+	grpcServer := grpcserver.New(cache, repo, broadcaster, log)
+	grpcListener, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		log.Error("listen grpc failed", "addr", cfg.GRPC.Addr, "error", err)
+		os.Exit(1)
+	}
 
+	// Порядок остановки: перестаем принимать HTTP и gRPC запросы, отправляем
+	// принятые по HTTP заказы, дожидаемся обработки текущего сообщения Kafka,
+	// затем закрываем пул соединений и выгружаем накопленные спаны.
+	app := lifecycle.New(log)
+	app.OnStop("http server", server.Shutdown)
+	app.OnStop("grpc server", grpcServer.Shutdown)
+	app.OnStop("kafka producer", func(context.Context) error { return producer.Close() })
+	app.OnStop("kafka consumer", consumer.Stop)
+	app.OnStop("database", func(context.Context) error { return sqlDB.Close() })
+	app.OnStop("tracing", shutdownTracing)
+
+	// HTTP сервер стартует до прогрева кэша, чтобы /healthz отвечал,
+	// а /readyz показывал, что сервис еще не готов.
+	serverErr := make(chan error, 2)
+	go func() {
+		log.Info("starting http server", "addr", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
 	go func() {
-		log.Println("Starting HTTP server on :8081")
-		http.ListenAndServe(":8081", nil)
+		log.Info("starting grpc server", "addr", cfg.GRPC.Addr)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			serverErr <- fmt.Errorf("grpc server: %w", err)
+		}
 	}()
 
-	log.Println("Application started successfully!")
-	waitForShutdown()
+	restoreCacheFromDB(repo, cache, cfg.Cache.Size, log)
+
+	consumer.Start()
+
+	log.Info("application started")
+	waitForShutdown(log, serverErr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := app.Shutdown(ctx); err != nil {
+		log.Error("shutdown finished with errors", "error", err)
+		return
+	}
+	log.Info("shutdown complete")
 }
 
-func restoreCacheFromDB(db *gorm.DB, cache *cache.Cache) {
-	log.Println("Restoring cache from database...")
+func restoreCacheFromDB(repo repository.OrderRepository, cache *cache.Cache, limit int, log *slog.Logger) {
+	log.Info("restoring cache from database")
+
+	// Кэш отмечается прогретым и при ошибке: промахи все равно читаются из базы.
+	defer cache.MarkWarm()
 
-	var orders []models.Order
-	db.Preload("Delivery").Preload("Payment").Preload("Items").Limit(1000).Find(&orders)
+	orders, err := repo.List(context.Background(), limit)
+	if err != nil {
+		log.Error("restore cache failed", "error", err)
+		return
+	}
 
 	for _, order := range orders {
 		cache.Set(order.OrderUID, &order)
 	}
 
-	log.Printf("Cache restored with %d orders", len(orders))
+	log.Info("cache restored", "orders", len(orders))
 }
 
-func waitForShutdown() {
+func waitForShutdown(log *slog.Logger, serverErr <-chan error) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
-	log.Println("Shutting down...")
+	select {
+	case sig := <-sigChan:
+		log.Info("shutting down", "signal", sig.String())
+	case err := <-serverErr:
+		log.Error("server failed, shutting down", "error", err)
+	}
 }
@@ -1,25 +1,307 @@
 package migrations
 
 import (
-	"log"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
 
-	"github.com/gegxkss/wbL0/internal/config"
-	"github.com/gegxkss/wbL0/internal/models"
+	"gorm.io/gorm"
 )
 
-func Migration() {
-	config.ConnectDB()
+//go:embed sql/*.sql
+var embedded embed.FS
 
-	err := config.DB.AutoMigrate(
-		&models.Order{},
-		&models.Delivery{},
-		&models.Payment{},
-		&models.Items{},
-	)
+// lockID — ключ advisory lock, чтобы два экземпляра сервиса не применяли
+// миграции одновременно.
+const lockID = 7245813001
 
+const createHistoryTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    bigint PRIMARY KEY,
+    name       text NOT NULL,
+    applied_at timestamptz NOT NULL DEFAULT now()
+)`
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+func (s Status) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+// Migrator применяет пронумерованные SQL миграции и ведет их историю
+// в таблице schema_migrations.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	log        *slog.Logger
+}
+
+// New создает Migrator для миграций, встроенных в бинарник.
+func New(db *gorm.DB, log *slog.Logger) (*Migrator, error) {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return NewFromFS(db, sub, log)
+}
+
+func NewFromFS(db *gorm.DB, fsys fs.FS, log *slog.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, log: log.With("component", "migrations")}, nil
+}
+
+// Load читает пары файлов NNNN_name.up.sql / NNNN_name.down.sql
+// и возвращает миграции по возрастанию версии.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest возвращает номер последней известной миграции.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version возвращает номер последней примененной миграции или 0.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	if err := m.ensureHistory(ctx); err != nil {
+		return 0, err
+	}
+	var version int
+	err := m.db.WithContext(ctx).Raw("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version).Error
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Status возвращает все известные миграции с отметкой о применении.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
 	if err != nil {
-		log.Fatalf("Migration failed: %v", err)
+		return nil, err
 	}
 
-	log.Println("Migration completed successfully")
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &at
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Up применяет все непримененные миграции.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down откатывает последнюю примененную миграцию.
+func (m *Migrator) Down(ctx context.Context) error {
+	current, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+	target := 0
+	for _, mig := range m.migrations {
+		if mig.Version < current {
+			target = mig.Version
+		}
+	}
+	return m.To(ctx, target)
+}
+
+// To применяет или откатывает миграции, пока схема не достигнет version.
+// version 0 откатывает все миграции.
+func (m *Migrator) To(ctx context.Context, version int) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok && mig.Version <= version {
+			if err := m.apply(ctx, mig, true); err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; ok && mig.Version > version {
+			if err := m.apply(ctx, mig, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, mig Migration, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	log := m.log.With("version", mig.Version, "name", mig.Name, "direction", direction)
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Построение индексов и перенос данных могут идти дольше
+		// statement_timeout рабочего пула, поэтому в миграции он снимается.
+		if err := tx.Exec("SET LOCAL statement_timeout = 0").Error; err != nil {
+			return fmt.Errorf("disable statement timeout: %w", err)
+		}
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockID).Error; err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+
+		// Другой экземпляр мог применить миграцию, пока мы ждали блокировку.
+		var count int64
+		if err := tx.Raw("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", mig.Version).Scan(&count).Error; err != nil {
+			return fmt.Errorf("read migration history: %w", err)
+		}
+		if (count > 0) == up {
+			return nil
+		}
+
+		if up {
+			if err := tx.Exec(mig.Up).Error; err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", mig.Version, mig.Name).Error
+		}
+		if err := tx.Exec(mig.Down).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", mig.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s %s: %w", mig.Version, mig.Name, direction, err)
+	}
+
+	log.Info("migration applied")
+	return nil
+}
+
+func (m *Migrator) ensureHistory(ctx context.Context) error {
+	if err := m.db.WithContext(ctx).Exec(createHistoryTable).Error; err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	if err := m.ensureHistory(ctx); err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Version   int
+		AppliedAt time.Time
+	}
+	if err := m.db.WithContext(ctx).Raw("SELECT version, applied_at FROM schema_migrations").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("read migration history: %w", err)
+	}
+
+	applied := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+// Apply применяет все встроенные миграции. Используется сервисом с флагом -m
+// и тестами, которым нужна актуальная схема.
+func Apply(ctx context.Context, db *gorm.DB, log *slog.Logger) error {
+	m, err := New(db, log)
+	if err != nil {
+		return err
+	}
+	return m.Up(ctx)
 }
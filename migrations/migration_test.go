@@ -1,24 +1,189 @@
 package migrations
 
 import (
+	"context"
+	"strings"
 	"testing"
-	"github.com/gegxkss/wbL0/internal/config"
+	"testing/fstest"
+
+	"github.com/gegxkss/wbL0/internal/logger"
+	"github.com/gegxkss/wbL0/internal/testdb"
+	"gorm.io/gorm"
 )
 
+var tables = []string{"orders", "deliveries", "payments", "items"}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id int);")},
+		"0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id int);")},
+		"0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+		"README.md":            {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "first" {
+		t.Errorf("Expected 0001_first first, got %04d_%s", migrations[0].Version, migrations[0].Name)
+	}
+	if migrations[1].Down != "DROP TABLE b;" {
+		t.Errorf("Expected down SQL of second migration, got %q", migrations[1].Down)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing down": {
+			"0001_first.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"bad name": {
+			"first.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"name mismatch": {
+			"0001_first.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_other.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"zero version": {
+			"0000_first.up.sql":   {Data: []byte("SELECT 1;")},
+			"0000_first.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(fsys); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	m, err := New(nil, logger.Nop())
+	if err != nil {
+		t.Fatalf("Embedded migrations are invalid: %v", err)
+	}
+	if m.Latest() == 0 {
+		t.Error("Expected at least one embedded migration")
+	}
+	for _, mig := range m.migrations {
+		if strings.TrimSpace(mig.Up) == "" || strings.TrimSpace(mig.Down) == "" {
+			t.Errorf("Migration %04d_%s has empty SQL", mig.Version, mig.Name)
+		}
+	}
+}
+
 func TestMigration(t *testing.T) {
-	Migration()
+	db := testdb.OpenClean(t)
+	ctx := context.Background()
+
+	m, err := New(db, logger.Nop())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
 
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
 	// Проверяем, что таблицы созданы
-	tables := []string{"orders", "deliveries", "payments", "items"}
 	for _, table := range tables {
-		var exists bool
-		// Проверяем наличие таблицы через запрос к information_schema.tables
-		err := config.DB.Raw("SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = ?)", table).Scan(&exists).Error
-		if err != nil {
-			t.Fatalf("Ошибка при проверке таблицы %s: %v", table, err)
-		}
-		if !exists {
+		if !tableExists(t, db, table) {
 			t.Errorf("Таблица %s не создана", table)
 		}
 	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	if version != m.Latest() {
+		t.Errorf("Expected version %d, got %d", m.Latest(), version)
+	}
+
+	// Повторный запуск ничего не меняет
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("Second Up failed: %v", err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied() {
+			t.Errorf("Migration %04d_%s is not applied", s.Version, s.Name)
+		}
+	}
+
+	if err := m.To(ctx, 0); err != nil {
+		t.Fatalf("To(0) failed: %v", err)
+	}
+	for _, table := range tables {
+		if tableExists(t, db, table) {
+			t.Errorf("Таблица %s не удалена", table)
+		}
+	}
+	if version, _ := m.Version(ctx); version != 0 {
+		t.Errorf("Expected version 0 after rollback, got %d", version)
+	}
+
+	// Схема восстанавливается после полного отката
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("Up after rollback failed: %v", err)
+	}
+	if err := m.Down(ctx); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	version, _ = m.Version(ctx)
+	if version >= m.Latest() {
+		t.Errorf("Expected version below %d after Down, got %d", m.Latest(), version)
+	}
+}
+
+func TestMigration_UnknownVersion(t *testing.T) {
+	db := testdb.OpenClean(t)
+
+	m, err := New(db, logger.Nop())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := m.To(context.Background(), 9999); err == nil {
+		t.Error("Expected error for unknown version, got nil")
+	}
+}
+
+func tableExists(t *testing.T, db *gorm.DB, table string) bool {
+	t.Helper()
+
+	var exists bool
+	// Проверяем наличие таблицы в текущей схеме через information_schema.tables
+	err := db.Raw("SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?)", table).Scan(&exists).Error
+	if err != nil {
+		t.Fatalf("Ошибка при проверке таблицы %s: %v", table, err)
+	}
+	return exists
+}
+
+func TestMigration_NoStatementTimeout(t *testing.T) {
+	db := testdb.OpenClean(t)
+
+	// Пул открыт с statement_timeout, а в миграции он должен быть снят
+	check := "DO $$ BEGIN IF current_setting('statement_timeout') <> '0' THEN " +
+		"RAISE EXCEPTION 'statement_timeout is %', current_setting('statement_timeout'); END IF; END $$;"
+	fsys := fstest.MapFS{
+		"0001_check.up.sql":   {Data: []byte(check)},
+		"0001_check.down.sql": {Data: []byte(check)},
+	}
+	m, err := NewFromFS(db, fsys, logger.Nop())
+	if err != nil {
+		t.Fatalf("NewFromFS failed: %v", err)
+	}
+	if err := m.Up(context.Background()); err != nil {
+		t.Errorf("Up failed: %v", err)
+	}
 }
@@ -0,0 +1,58 @@
+-- Исходная схема, совпадающая с тем, что создавал GORM AutoMigrate.
+-- IF NOT EXISTS позволяет применить миграцию к уже существующей базе.
+CREATE TABLE IF NOT EXISTS orders (
+    order_uid          text PRIMARY KEY,
+    track_number       text,
+    entry              text,
+    locale             text,
+    internal_signature text,
+    customer_id        text,
+    delivery_service   text,
+    shardkey           text,
+    sm_id              bigint,
+    date_created       timestamptz,
+    oof_shard          text
+);
+
+CREATE TABLE IF NOT EXISTS deliveries (
+    id        bigserial PRIMARY KEY,
+    order_uid text NOT NULL,
+    name      text,
+    phone     text,
+    zip       text,
+    city      text,
+    address   text,
+    region    text,
+    email     text
+);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id            bigserial PRIMARY KEY,
+    order_uid     text NOT NULL,
+    transaction   text,
+    request_id    text,
+    currency      text,
+    provider      text,
+    amount        bigint,
+    payment_dt    bigint,
+    bank          text,
+    delivery_cost bigint,
+    goods_total   bigint,
+    custom_fee    bigint
+);
+
+CREATE TABLE IF NOT EXISTS items (
+    id           bigserial PRIMARY KEY,
+    order_uid    text NOT NULL,
+    chrt_id      bigint,
+    track_number text,
+    price        bigint,
+    rid          text,
+    name         text,
+    sale         bigint,
+    size         text,
+    total_price  bigint,
+    nm_id        bigint,
+    brand        text,
+    status       bigint
+);
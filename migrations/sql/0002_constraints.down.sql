@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_orders_customer_id;
+DROP INDEX IF EXISTS idx_orders_track_number;
+DROP INDEX IF EXISTS idx_items_order_uid;
+
+ALTER TABLE items DROP CONSTRAINT IF EXISTS fk_items_order;
+
+ALTER TABLE payments
+    DROP CONSTRAINT IF EXISTS uq_payments_order_uid,
+    DROP CONSTRAINT IF EXISTS fk_payments_order;
+
+ALTER TABLE deliveries
+    DROP CONSTRAINT IF EXISTS uq_deliveries_order_uid,
+    DROP CONSTRAINT IF EXISTS fk_deliveries_order;
@@ -0,0 +1,26 @@
+-- Удаляем строки, которые нарушили бы новые ограничения: дочерние записи
+-- без заказа и повторные доставки/оплаты (оставляем первую по id).
+DELETE FROM deliveries d WHERE NOT EXISTS (SELECT 1 FROM orders o WHERE o.order_uid = d.order_uid);
+DELETE FROM payments p WHERE NOT EXISTS (SELECT 1 FROM orders o WHERE o.order_uid = p.order_uid);
+DELETE FROM items i WHERE NOT EXISTS (SELECT 1 FROM orders o WHERE o.order_uid = i.order_uid);
+
+DELETE FROM deliveries d USING deliveries dup
+WHERE d.order_uid = dup.order_uid AND d.id > dup.id;
+DELETE FROM payments p USING payments dup
+WHERE p.order_uid = dup.order_uid AND p.id > dup.id;
+
+ALTER TABLE deliveries
+    ADD CONSTRAINT fk_deliveries_order FOREIGN KEY (order_uid) REFERENCES orders (order_uid) ON DELETE CASCADE,
+    ADD CONSTRAINT uq_deliveries_order_uid UNIQUE (order_uid);
+
+ALTER TABLE payments
+    ADD CONSTRAINT fk_payments_order FOREIGN KEY (order_uid) REFERENCES orders (order_uid) ON DELETE CASCADE,
+    ADD CONSTRAINT uq_payments_order_uid UNIQUE (order_uid);
+
+ALTER TABLE items
+    ADD CONSTRAINT fk_items_order FOREIGN KEY (order_uid) REFERENCES orders (order_uid) ON DELETE CASCADE;
+
+-- Уникальные ограничения deliveries/payments уже создают индекс по order_uid.
+CREATE INDEX idx_items_order_uid ON items (order_uid);
+CREATE INDEX idx_orders_track_number ON orders (track_number);
+CREATE INDEX idx_orders_customer_id ON orders (customer_id);
@@ -0,0 +1,29 @@
+CREATE FUNCTION pg_temp.currency_scale(currency text) RETURNS bigint AS $$
+    SELECT CASE
+        WHEN upper(currency) IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW',
+                                 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+        WHEN upper(currency) IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+        ELSE 100
+    END
+$$ LANGUAGE sql IMMUTABLE;
+
+UPDATE payments SET
+    amount        = amount / pg_temp.currency_scale(currency),
+    delivery_cost = delivery_cost / pg_temp.currency_scale(currency),
+    goods_total   = goods_total / pg_temp.currency_scale(currency),
+    custom_fee    = custom_fee / pg_temp.currency_scale(currency);
+
+UPDATE items SET
+    price       = price / pg_temp.currency_scale(currency),
+    total_price = total_price / pg_temp.currency_scale(currency);
+
+COMMENT ON COLUMN payments.amount IS NULL;
+COMMENT ON COLUMN payments.delivery_cost IS NULL;
+COMMENT ON COLUMN payments.goods_total IS NULL;
+COMMENT ON COLUMN payments.custom_fee IS NULL;
+COMMENT ON COLUMN items.price IS NULL;
+COMMENT ON COLUMN items.total_price IS NULL;
+
+ALTER TABLE items DROP COLUMN currency;
+
+DROP FUNCTION pg_temp.currency_scale(text);
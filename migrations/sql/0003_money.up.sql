@@ -0,0 +1,36 @@
+-- Суммы переводятся из основных единиц валюты в минимальные (центы, копейки),
+-- у товаров появляется своя колонка валюты. Список валют с нестандартным
+-- числом знаков совпадает с models.CurrencyExponent.
+CREATE FUNCTION pg_temp.currency_scale(currency text) RETURNS bigint AS $$
+    SELECT CASE
+        WHEN upper(currency) IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW',
+                                 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+        WHEN upper(currency) IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+        ELSE 100
+    END
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE items ADD COLUMN currency text NOT NULL DEFAULT '';
+
+UPDATE items i SET currency = p.currency
+FROM payments p
+WHERE p.order_uid = i.order_uid;
+
+UPDATE payments SET
+    amount        = amount * pg_temp.currency_scale(currency),
+    delivery_cost = delivery_cost * pg_temp.currency_scale(currency),
+    goods_total   = goods_total * pg_temp.currency_scale(currency),
+    custom_fee    = custom_fee * pg_temp.currency_scale(currency);
+
+UPDATE items SET
+    price       = price * pg_temp.currency_scale(currency),
+    total_price = total_price * pg_temp.currency_scale(currency);
+
+COMMENT ON COLUMN payments.amount IS 'minor units of payments.currency';
+COMMENT ON COLUMN payments.delivery_cost IS 'minor units of payments.currency';
+COMMENT ON COLUMN payments.goods_total IS 'minor units of payments.currency';
+COMMENT ON COLUMN payments.custom_fee IS 'minor units of payments.currency';
+COMMENT ON COLUMN items.price IS 'minor units of items.currency';
+COMMENT ON COLUMN items.total_price IS 'minor units of items.currency';
+
+DROP FUNCTION pg_temp.currency_scale(text);
@@ -0,0 +1,6 @@
+ALTER TABLE payments ADD COLUMN payment_dt_unix bigint;
+
+UPDATE payments SET payment_dt_unix = extract(epoch FROM payment_dt)::bigint;
+
+ALTER TABLE payments DROP COLUMN payment_dt;
+ALTER TABLE payments RENAME COLUMN payment_dt_unix TO payment_dt;
@@ -0,0 +1,8 @@
+-- payment_dt хранился как Unix-время в секундах. Переносим значения
+-- в колонку timestamptz и заменяем ею старую.
+ALTER TABLE payments ADD COLUMN payment_dt_ts timestamptz;
+
+UPDATE payments SET payment_dt_ts = to_timestamp(payment_dt);
+
+ALTER TABLE payments DROP COLUMN payment_dt;
+ALTER TABLE payments RENAME COLUMN payment_dt_ts TO payment_dt;
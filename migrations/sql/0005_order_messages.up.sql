@@ -0,0 +1,13 @@
+-- Исходные сообщения Kafka, из которых сохранены заказы.
+CREATE TABLE order_messages (
+    order_uid    text PRIMARY KEY REFERENCES orders (order_uid) ON DELETE CASCADE,
+    topic        text NOT NULL,
+    partition    integer NOT NULL,
+    kafka_offset bigint NOT NULL,
+    key          text NOT NULL DEFAULT '',
+    headers      jsonb NOT NULL DEFAULT '[]',
+    payload      jsonb NOT NULL,
+    timestamp    timestamptz
+);
+
+CREATE INDEX idx_order_messages_position ON order_messages (topic, partition, kafka_offset);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_items_brand;
+DROP INDEX IF EXISTS idx_orders_locale;
+DROP INDEX IF EXISTS idx_orders_delivery_service;
+CREATE INDEX idx_orders_customer_id ON orders (customer_id);
+DROP INDEX IF EXISTS idx_orders_customer_date;
+DROP INDEX IF EXISTS idx_orders_date_created;
@@ -0,0 +1,8 @@
+-- Индексы для GET /orders: сортировка по дате с курсором и фильтры.
+-- Составной индекс по customer_id заменяет одиночный из 0002.
+CREATE INDEX idx_orders_date_created ON orders (date_created DESC, order_uid DESC);
+CREATE INDEX idx_orders_customer_date ON orders (customer_id, date_created DESC, order_uid DESC);
+DROP INDEX IF EXISTS idx_orders_customer_id;
+CREATE INDEX idx_orders_delivery_service ON orders (delivery_service);
+CREATE INDEX idx_orders_locale ON orders (locale);
+CREATE INDEX idx_items_brand ON items (brand, order_uid);
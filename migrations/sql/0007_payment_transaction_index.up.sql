@@ -0,0 +1,2 @@
+-- Поиск заказа по ID транзакции оплаты: GET /orders/by-transaction/{tx}.
+CREATE INDEX idx_payments_transaction ON payments (transaction);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_deliveries_phone_trgm;
+DROP INDEX IF EXISTS idx_deliveries_city_trgm;
+DROP INDEX IF EXISTS idx_deliveries_name_trgm;
+DROP INDEX IF EXISTS idx_items_brand_trgm;
+DROP INDEX IF EXISTS idx_items_name_trgm;
+
+-- Расширение не удаляется: его могут использовать другие объекты базы.
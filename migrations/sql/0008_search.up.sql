@@ -0,0 +1,9 @@
+-- Поиск заказов (GET /search): GIN индексы pg_trgm ускоряют и ILIKE '%...%',
+-- и нечеткое сравнение по словам (<%).
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_items_name_trgm ON items USING gin (name gin_trgm_ops);
+CREATE INDEX idx_items_brand_trgm ON items USING gin (brand gin_trgm_ops);
+CREATE INDEX idx_deliveries_name_trgm ON deliveries USING gin (name gin_trgm_ops);
+CREATE INDEX idx_deliveries_city_trgm ON deliveries USING gin (city gin_trgm_ops);
+CREATE INDEX idx_deliveries_phone_trgm ON deliveries USING gin (phone gin_trgm_ops);
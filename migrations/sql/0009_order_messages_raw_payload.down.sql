@@ -0,0 +1 @@
+ALTER TABLE order_messages DROP COLUMN IF EXISTS raw_payload;
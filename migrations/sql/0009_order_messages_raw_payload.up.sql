@@ -0,0 +1,4 @@
+-- jsonb нормализует тело сообщения (пробелы, порядок и повторы ключей),
+-- поэтому рядом хранится точная копия байтов. У сообщений, сохраненных
+-- до этой миграции, копии нет.
+ALTER TABLE order_messages ADD COLUMN raw_payload bytea;
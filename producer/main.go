@@ -1,42 +1,80 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/gegxkss/wbL0/internal/config"
+	"github.com/gegxkss/wbL0/internal/logger"
 	"github.com/gegxkss/wbL0/internal/models"
+	"github.com/gegxkss/wbL0/internal/tracing"
 	"github.com/gegxkss/wbL0/kafka"
 	"github.com/google/uuid"
 )
 
 func main() {
-	kafkaAddresses := []string{"localhost:9091", "localhost:9092", "localhost:9093"}
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.Getenv)
+	if err != nil {
+		slog.Error("load config failed", "error", err)
+		os.Exit(1)
+	}
+
+	log, err := logger.New(os.Stdout, cfg.Log.Level)
+	if err != nil {
+		slog.Error("invalid log level", "error", err)
+		os.Exit(1)
+	}
 
-	producer, err := kafka.NewProducer(kafkaAddresses)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "wbl0-producer",
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+	})
 	if err != nil {
-		log.Fatalf("Failed to create producer: %v", err)
+		log.Error("set up tracing failed", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	producer, err := kafka.NewProducer(cfg.Kafka.Brokers)
+	if err != nil {
+		log.Error("create producer failed", "error", err)
+		os.Exit(1)
 	}
 	defer producer.Close()
 
-	log.Println("Producer started. Sending test orders to Kafka...")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Info("producer started, sending test orders")
 	counter := 1
 
 	for {
 		order := generateTestOrder(counter)
 		message, _ := json.Marshal(order)
 
-		err = producer.Produce(string(message), "order", order.OrderUID)
+		err = producer.Produce(string(message), cfg.Kafka.Topic, order.OrderUID)
 		if err != nil {
-			log.Printf("Error producing message: %v", err)
+			log.Error("produce message failed", "order_uid", order.OrderUID, "error", err)
 		} else {
-			log.Printf("Order %d sent: %s", counter, order.OrderUID)
+			log.Info("order sent", "order_uid", order.OrderUID, "counter", counter)
 		}
 
 		counter++
-		time.Sleep(5 * time.Second)
+		select {
+		case <-ctx.Done():
+			log.Info("producer stopped")
+			return
+		case <-time.After(5 * time.Second):
+		}
 	}
 }
 
@@ -48,6 +86,11 @@ func generateTestOrder(id int) models.Order {
 	productIndex := rand.Intn(len(products))
 	brandIndex := rand.Intn(len(brands))
 
+	price := models.NewMoney(int64(45300+id*200), "USD")
+	goodsTotal := models.NewMoney(int64(31700+id*500), "USD")
+	deliveryCost := models.NewMoney(150000, "USD")
+	amount, _ := goodsTotal.Add(deliveryCost)
+
 	return models.Order{
 		OrderUID:          orderUID,
 		TrackNumber:       fmt.Sprintf("WBILMTESTTRACK%d", id),
@@ -73,22 +116,22 @@ func generateTestOrder(id int) models.Order {
 			Transaction:  orderUID,
 			Currency:     "USD",
 			Provider:     "wbpay",
-			Amount:       1817 + id*10,
-			PaymentDt:    int(time.Now().Unix()),
+			Amount:       amount,
+			PaymentDt:    time.Now().UTC(),
 			Bank:         "alpha",
-			DeliveryCost: 1500,
-			GoodsTotal:   317 + id*5,
+			DeliveryCost: deliveryCost,
+			GoodsTotal:   goodsTotal,
 		},
 		Items: []models.Items{
 			{
 				ChrtId:      9934930 + id,
 				Tracknumber: fmt.Sprintf("WBILMTESTTRACK%d", id),
-				Price:       453 + id*2,
+				Price:       price,
 				Rid:         fmt.Sprintf("ab4219087a764ae0btest%d", id),
 				Name:        products[productIndex],
 				Sale:        30,
 				Size:        "0",
-				TotalPrice:  317 + id*5,
+				TotalPrice:  goodsTotal,
 				NmId:        2389212 + id,
 				Brand:       brands[brandIndex],
 				Status:      202,